@@ -1,14 +1,16 @@
 package argp
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"reflect"
-	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 	"unicode/utf8"
 )
@@ -23,8 +25,19 @@ type Var struct {
 	Short       rune // 0 if not used
 	Index       int  // -1 if not used
 	Rest        bool
+	Min         int         // minimum number of values of a variadic argument
+	Max         int         // maximum number of values of a variadic argument, -1 if unlimited and 0 if not variadic
 	Default     interface{} // nil is not used
 	Description string
+	Placeholder string // name of the value in help message instead of its type
+	Optional    bool   // value is optional and must be attached, e.g. --color=always
+	Implicit    string // value when an optional value is not given
+	Stop        bool   // stop parsing options after this argument
+	Group       string // section in help message, empty for default
+	Hidden      bool   // hide from help message and documentation
+	Indirect    bool   // resolve values with a registered prefix, such as file:name, see AddIndirection
+	Path        string // comma-separated path options of a string or []string: file, dir, exists, create, and glob, see the path tag
+	Secret      bool   // never show the value, which is indirect and should not be given on the command line, see Secret
 	isSet       bool
 }
 
@@ -38,6 +51,51 @@ func (v *Var) IsArgument() bool {
 	return v.Index != -1 || v.Rest
 }
 
+// IsVariadic returns true for an argument that takes multiple values
+func (v *Var) IsVariadic() bool {
+	return v.Rest || v.Index != -1 && v.Max != 0
+}
+
+// scan parses the option's value, which is resolved first when indirect and the value has a registered prefix. Paths are resolved and checked afterwards. It returns whether the value was indirect.
+func (v *Var) scan(name string, s []string) (int, bool, error) {
+	n := 1
+	contents, indirect, err := v.resolveIndirection(name, s)
+	if err != nil {
+		return 0, false, err
+	} else if indirect {
+		err = scanIndirection(v.Value, name, contents)
+	} else {
+		n, err = scanVar(v.Value, name, s)
+	}
+	if err != nil && v.Secret {
+		// do not show the value
+		err = fmt.Errorf("invalid secret")
+	} else if err == nil && v.Path != "" {
+		err = resolvePaths(v.Value, v.Path, "", true)
+	}
+	return n, indirect, err
+}
+
+// resolveIndirection returns the contents referred to by the first value if indirect. A secret from standard input is read without echo from a terminal.
+func (v *Var) resolveIndirection(name string, s []string) (string, bool, error) {
+	if !v.Indirect || len(s) == 0 {
+		return "", false, nil
+	} else if v.Secret && s[0] == "-" {
+		secret, err := readSecret(name)
+		return secret, true, err
+	}
+	return resolveIndirection(s[0])
+}
+
+// wrap replaces the variable's value by a wrapper such as Merge. The default value is set directly, since it can not be set through the wrapper.
+func (v *Var) wrap(custom Custom) {
+	if v.Default != nil {
+		v.Value.Set(reflect.ValueOf(v.Default))
+		v.Default = nil
+	}
+	v.Value = reflect.ValueOf(custom)
+}
+
 // Set sets the variable's value
 func (v *Var) Set(i interface{}) bool {
 	val := reflect.ValueOf(i)
@@ -53,16 +111,45 @@ type Cmd interface {
 	Run() error
 }
 
+// CmdInfo is the long description, examples, and epilog of a command's help message.
+type CmdInfo struct {
+	LongDescription string   // shown at the top of the help message
+	Examples        []string // example invocations
+	Epilog          string   // shown at the bottom of the help message
+}
+
+// InfoCmd can be implemented by a command to set the long description, examples, and epilog of its help message.
+type InfoCmd interface {
+	Info() CmdInfo
+}
+
 // Argp is a (sub) command parser
 type Argp struct {
 	Cmd
-	Description string
-
-	parent *Argp
-	name   string
-	vars   []*Var
-	cmds   map[string]*Argp
-	help   bool
+	Description     string   // short description shown in the list of commands of the parent
+	LongDescription string   // shown at the top of the help message
+	Examples        []string // example invocations
+	Epilog          string   // shown at the bottom of the help message
+	GroupOrder      []string // order of option groups in the help message, unlisted groups follow in order of declaration
+	KeepOrder       bool     // list options in order of declaration instead of alphabetically
+	Formatter       HelpFormatter
+	Hidden          bool   // hide from the list of commands of the parent and documentation
+	VersionTemplate string // template for the version, see SetVersion
+	PassUnknown     bool   // pass unknown options through to the remaining arguments instead of failing
+	Strict          bool   // stop parsing options at the first non-option argument for this command and its sub commands, also enabled by the POSIXLY_CORRECT environment variable
+	NegativeNumbers bool   // parse arguments such as -5 or -1e3 as negative numbers when no short option is a digit or when a numeric argument is expected, true by default
+	ResponseFiles   bool   // replace arguments such as @file by the arguments in the file, see expandResponseFile
+
+	parent  *Argp
+	name    string
+	vars    []*Var
+	cmds    map[string]*Argp
+	help    bool
+	helpAll bool
+	docs    string
+
+	version     string
+	showVersion bool
 
 	Error *log.Logger
 }
@@ -75,10 +162,11 @@ func New(description string) *Argp {
 // NewCmd returns a new command parser that invokes the Run method of the passed command structure. The `Argp.Parse()` function will not return and will call os.Exit() with 0, 1 or 2 as the argument.
 func NewCmd(cmd Cmd, description string) *Argp {
 	argp := &Argp{
-		Cmd:         cmd,
-		Description: description,
-		name:        filepath.Base(os.Args[0]),
-		cmds:        map[string]*Argp{},
+		Cmd:             cmd,
+		Description:     description,
+		NegativeNumbers: true,
+		name:            filepath.Base(os.Args[0]),
+		cmds:            map[string]*Argp{},
 	}
 	if cmd != nil {
 		v := reflect.ValueOf(cmd)
@@ -91,104 +179,221 @@ func NewCmd(cmd Cmd, description string) *Argp {
 		}
 
 		maxIndex := -1
-		for j := 0; j < v.NumField(); j++ {
-			tfield := v.Type().Field(j)
-			vfield := v.Field(j)
-			if vfield.IsValid() {
-				variable := &Var{}
-				variable.Value = vfield
-				variable.Name = fromFieldname(tfield.Name)
-				variable.Index = -1
-				option := reflect.TypeOf(cmd).String() + "." + tfield.Name
-
-				if !isValidType(vfield.Type()) {
-					panic(fmt.Sprintf("unsupported type %s", vfield.Type()))
+		argp.addFields(v, reflect.TypeOf(cmd).String(), "", "", &maxIndex)
+		if cmd, ok := cmd.(InfoCmd); ok {
+			info := cmd.Info()
+			argp.LongDescription, argp.Examples, argp.Epilog = info.LongDescription, info.Examples, info.Epilog
+		}
+		for i := 0; i <= maxIndex; i++ {
+			if v := argp.findIndex(i); v == nil {
+				panic(fmt.Sprintf("option indices must be continuous: index %v is missing", i))
+			}
+		}
+	}
+	if argp.findName("help") == nil {
+		if argp.findShort('h') == nil {
+			argp.AddOpt(&argp.help, "h", "help", "Help")
+		} else {
+			argp.AddOpt(&argp.help, "", "help", "Help")
+		}
+	}
+	if argp.findName("generate-docs") == nil {
+		argp.AddOpt(&argp.docs, "", "generate-docs", "Generate man pages and Markdown documentation in directory").Hidden = true
+	}
+	return argp
+}
+
+// addFields adds the fields of struct v as options and arguments. Struct fields with the embed tag are flattened into separate options, with their names prefixed by the prefix tag and listed in the help message under a section named by the group or desc tag.
+func (argp *Argp) addFields(v reflect.Value, path, prefix, group string, maxIndex *int) {
+	for j := 0; j < v.NumField(); j++ {
+		tfield := v.Type().Field(j)
+		vfield := v.Field(j)
+		if vfield.IsValid() {
+			option := path + "." + tfield.Name
+			if _, ok := tfield.Tag.Lookup("embed"); ok {
+				if vfield.Kind() != reflect.Struct {
+					panic(fmt.Sprintf("%v: embedded option group must be a struct", option))
+				}
+				embedGroup := group
+				if tagGroup, ok := tfield.Tag.Lookup("group"); ok {
+					embedGroup = tagGroup
+				} else if desc := tfield.Tag.Get("desc"); desc != "" {
+					embedGroup = desc
 				}
+				argp.addFields(vfield, option, prefix+strings.ToLower(tfield.Tag.Get("prefix")), embedGroup, maxIndex)
+				continue
+			}
 
-				name, hasName := tfield.Tag.Lookup("name")
-				short := tfield.Tag.Get("short")
-				index := tfield.Tag.Get("index")
-				def, hasDef := tfield.Tag.Lookup("default")
-				description := tfield.Tag.Get("desc")
+			variable := &Var{}
+			variable.Value = vfield
+			variable.Name = fromFieldname(tfield.Name)
+			variable.Index = -1
+			variable.Group = group
+			if tagGroup, ok := tfield.Tag.Lookup("group"); ok {
+				variable.Group = tagGroup
+			}
 
-				if hasName {
-					variable.Name = strings.ToLower(name)
-				}
-				if variable.Name == "" {
-					variable.Name = short
+			if !isValidType(vfield.Type()) {
+				panic(fmt.Sprintf("unsupported type %s", vfield.Type()))
+			}
+
+			name, hasName := tfield.Tag.Lookup("name")
+			short := tfield.Tag.Get("short")
+			index := tfield.Tag.Get("index")
+			nargs, hasNargs := tfield.Tag.Lookup("nargs")
+			def, hasDef := tfield.Tag.Lookup("default")
+			description := tfield.Tag.Get("desc")
+
+			if hasName {
+				variable.Name = strings.ToLower(name)
+			}
+			if variable.Name != "" {
+				variable.Name = prefix + variable.Name
+			} else {
+				variable.Name = short
+			}
+			if !isValidName(variable.Name) {
+				panic(fmt.Sprintf("%v: invalid option name: --%v", option, variable.Name))
+			} else if argp.findName(variable.Name) != nil {
+				panic(fmt.Sprintf("%v: option name already exists: --%v", option, variable.Name))
+			}
+			if short != "" {
+				if !isValidName(short) {
+					panic(fmt.Sprintf("%v: invalid short option name: --%v", option, short))
 				}
-				if !isValidName(variable.Name) {
-					panic(fmt.Sprintf("%v: invalid option name: --%v", option, variable.Name))
-				} else if argp.findName(variable.Name) != nil {
-					panic(fmt.Sprintf("%v: option name already exists: --%v", option, variable.Name))
+				r, n := utf8.DecodeRuneInString(short)
+				if len(short) != n || n == 0 {
+					panic(fmt.Sprintf("%v: short option name must be one character: -%v", option, short))
+				} else if argp.findShort(r) != nil {
+					panic(fmt.Sprintf("%v: short option name already exists: -%v", option, string(r)))
 				}
+				variable.Short = r
+			}
+			if index != "" {
 				if short != "" {
-					if !isValidName(short) {
-						panic(fmt.Sprintf("%v: invalid short option name: --%v", option, short))
-					}
-					r, n := utf8.DecodeRuneInString(short)
-					if len(short) != n || n == 0 {
-						panic(fmt.Sprintf("%v: short option name must be one character: -%v", option, short))
-					} else if argp.findShort(r) != nil {
-						panic(fmt.Sprintf("%v: short option name already exists: -%v", option, string(r)))
-					}
-					variable.Short = r
+					panic(fmt.Sprintf("%v: can not set both an option short name and index", option))
 				}
-				if index != "" {
-					if short != "" {
-						panic(fmt.Sprintf("%v: can not set both an option short name and index", option))
+				if index == "*" {
+					if argp.findRest() != nil {
+						panic(fmt.Sprintf("%v: rest option already exists", option))
+					} else if def != "" {
+						panic(fmt.Sprintf("%v: rest option can not have a default value", option))
+					} else if variable.Value.Kind() != reflect.Slice {
+						panic(fmt.Sprintf("%v: rest option must be a slice", option))
 					}
-					if index == "*" {
-						if argp.findRest() != nil {
-							panic(fmt.Sprintf("%v: rest option already exists", option))
-						} else if def != "" {
-							panic(fmt.Sprintf("%v: rest option can not have a default value", option))
-						} else if variable.Value.Kind() != reflect.Slice || variable.Value.Type().Elem().Kind() != reflect.String {
-							panic(fmt.Sprintf("%v: rest option must be of type []string", option))
-						}
-						variable.Rest = true
-					} else {
-						i, err := strconv.Atoi(index)
-						if err != nil || i < 0 {
-							panic(fmt.Sprintf("%v: index must be a non-negative integer or *", option))
-						} else if argp.findIndex(i) != nil {
-							panic(fmt.Sprintf("%v: option index already exists: %v", option, i))
-						}
-						variable.Index = i
-						if maxIndex < i {
-							maxIndex = i
-						}
+					variable.Rest = true
+					variable.Max = -1
+				} else {
+					i, err := strconv.Atoi(index)
+					if err != nil || i < 0 {
+						panic(fmt.Sprintf("%v: index must be a non-negative integer or *", option))
+					} else if argp.findIndex(i) != nil {
+						panic(fmt.Sprintf("%v: option index already exists: %v", option, i))
 					}
-				}
-				if hasDef {
-					defVal := reflect.New(vfield.Type()).Elem()
-					if _, err := scanVar(defVal, "", splitArguments(def)); err != nil {
-						panic(fmt.Sprintf("%v: bad default value: %v", option, err))
+					variable.Index = i
+					if *maxIndex < i {
+						*maxIndex = i
 					}
-					variable.Default = defVal.Interface()
-				} else if variable.Index != -1 {
-					variable.Default = vfield.Interface()
 				}
-				if description != "" {
-					variable.Description = description
+			}
+			if hasNargs {
+				if !variable.IsArgument() {
+					panic(fmt.Sprintf("%v: nargs is only allowed for arguments", option))
+				} else if variable.Value.Kind() != reflect.Slice {
+					panic(fmt.Sprintf("%v: variadic argument must be a slice", option))
+				}
+				var err error
+				if variable.Min, variable.Max, err = parseNargs(nargs); err != nil {
+					panic(fmt.Sprintf("%v: %v", option, err))
 				}
-				argp.vars = append(argp.vars, variable)
 			}
-		}
-		for i := 0; i <= maxIndex; i++ {
-			if v := argp.findIndex(i); v == nil {
-				panic(fmt.Sprintf("option indices must be continuous: index %v is missing", i))
+			if hasDef {
+				defVal := reflect.New(vfield.Type()).Elem()
+				var err error
+				if custom := wrapField(option, tfield.Tag, defVal); custom != nil {
+					_, err = custom.Scan("", splitArguments(def))
+				} else {
+					_, err = scanVar(defVal, "", splitArguments(def))
+				}
+				if err != nil {
+					panic(fmt.Sprintf("%v: bad default value: %v", option, err))
+				}
+				variable.Default = defVal.Interface()
+			} else if variable.Index != -1 {
+				variable.Default = vfield.Interface()
+			}
+			if description != "" {
+				variable.Description = description
 			}
+			variable.Placeholder = tfield.Tag.Get("placeholder")
+			variable.Implicit, variable.Optional = tfield.Tag.Lookup("optional")
+			if variable.Optional && variable.IsArgument() {
+				panic(fmt.Sprintf("%v: argument can not have an optional value", option))
+			} else if variable.Optional {
+				implicitVal := reflect.New(vfield.Type()).Elem()
+				var err error
+				if custom := wrapField(option, tfield.Tag, implicitVal); custom != nil {
+					_, err = custom.Scan("", []string{variable.Implicit})
+				} else {
+					_, err = scanVar(implicitVal, "", []string{variable.Implicit})
+				}
+				if err != nil {
+					panic(fmt.Sprintf("%v: bad implicit value: %v", option, err))
+				}
+			}
+			if _, variable.Stop = tfield.Tag.Lookup("stop"); variable.Stop && variable.Index == -1 {
+				panic(fmt.Sprintf("%v: stop is only allowed for indexed arguments", option))
+			}
+			if _, variable.Indirect = tfield.Tag.Lookup("indirect"); variable.Indirect && variable.IsArgument() {
+				panic(fmt.Sprintf("%v: indirect is only allowed for options", option))
+			}
+			if _, ok := tfield.Tag.Lookup("secret"); ok || vfield.Type() == secretType {
+				if vfield.Kind() != reflect.String {
+					panic(fmt.Sprintf("%v: secret must be a string", option))
+				}
+				variable.Secret = true
+				variable.Indirect = true
+			}
+			if path, ok := tfield.Tag.Lookup("path"); ok {
+				checkPathOptions(option, path, vfield.Type())
+				variable.Path = path
+			}
+			if custom := wrapField(option, tfield.Tag, vfield); custom != nil {
+				variable.wrap(custom)
+			}
+			argp.vars = append(argp.vars, variable)
 		}
 	}
-	if argp.findName("help") == nil {
-		if argp.findShort('h') == nil {
-			argp.AddOpt(&argp.help, "h", "help", "Help")
-		} else {
-			argp.AddOpt(&argp.help, "", "help", "Help")
-		}
+}
+
+// wrapField returns the wrapper of a struct field as set by the merge, unit, or encoding tag, or nil otherwise.
+func wrapField(option string, tag reflect.StructTag, v reflect.Value) Custom {
+	if _, ok := tag.Lookup("merge"); ok {
+		if v.Kind() != reflect.Map && v.Kind() != reflect.Struct {
+			panic(fmt.Sprintf("%v: merge option must be a map or struct", option))
+		}
+		return Merge{v.Addr().Interface()}
+	} else if unit, ok := tag.Lookup("unit"); ok {
+		if unit != "bytes" && unit != "percent" {
+			panic(fmt.Sprintf("%v: unit must be bytes or percent", option))
+		} else if unit == "bytes" && (!isNumericType(v.Type()) || v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64) {
+			panic(fmt.Sprintf("%v: bytes unit must be an integer", option))
+		} else if unit == "percent" && v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+			panic(fmt.Sprintf("%v: percent unit must be a float", option))
+		}
+		return Unit{v.Addr().Interface(), unit}
+	} else if encoding, ok := tag.Lookup("encoding"); ok {
+		if encoding != "hex" && encoding != "base64" {
+			panic(fmt.Sprintf("%v: encoding must be hex or base64", option))
+		} else if !isBytesType(v.Type()) {
+			panic(fmt.Sprintf("%v: encoding option must be a byte slice or array", option))
+		}
+		return Encoding{v.Addr().Interface(), encoding}
+	} else if custom, ok := v.Addr().Interface().(Custom); ok && !v.Type().Implements(customType) {
+		// pointer receiver such as InputFile
+		return custom
 	}
-	return argp
+	return nil
 }
 
 // AddCmd adds a sub command
@@ -199,6 +404,10 @@ func (argp *Argp) AddCmd(cmd Cmd, name, description string) *Argp {
 		panic("invalid command name")
 	}
 
+	if len(argp.cmds) == 0 && argp.findName("help-all") == nil {
+		argp.AddOpt(&argp.helpAll, "", "help-all", "Help for all commands")
+	}
+
 	sub := NewCmd(cmd, description)
 	sub.parent = argp
 	sub.name = name
@@ -214,8 +423,13 @@ func (argp *Argp) IsSet(name string) bool {
 	return false
 }
 
-// AddOpt adds an option
-func (argp *Argp) AddOpt(dst interface{}, short, name string, description string) {
+// AddOpt adds an option. The returned variable can be used to set additional properties such as its group.
+func (argp *Argp) AddOpt(dst interface{}, short, name string, description string) *Var {
+	optional, isOptional := dst.(Optional)
+	if isOptional {
+		dst = optional.I
+	}
+
 	v := reflect.ValueOf(dst)
 	_, isCustom := dst.(Custom)
 	if !isCustom && v.Type().Kind() != reflect.Ptr {
@@ -259,11 +473,22 @@ func (argp *Argp) AddOpt(dst interface{}, short, name string, description string
 		variable.Default = v.Interface()
 	}
 	variable.Description = description
+	variable.Secret = v.Type() == secretType
+	variable.Indirect = variable.Secret
+	variable.Optional = isOptional
+	variable.Implicit = optional.Implicit
+	if isOptional && !isCustom {
+		implicitVal := reflect.New(v.Type()).Elem()
+		if _, err := scanVar(implicitVal, "", []string{variable.Implicit}); err != nil {
+			panic(fmt.Sprintf("--%v: bad implicit value: %v", variable.Name, err))
+		}
+	}
 	argp.vars = append(argp.vars, variable)
+	return variable
 }
 
-// AddArg adds an indexed value
-func (argp *Argp) AddArg(dst interface{}, name, description string) {
+// AddArg adds an indexed value. For a slice, set Min and Max of the returned variable to make it variadic so that it takes multiple arguments, such as SRC... in `cp SRC... DST`.
+func (argp *Argp) AddArg(dst interface{}, name, description string) *Var {
 	v := reflect.ValueOf(dst)
 	_, isCustom := dst.(Custom)
 	if !isCustom && v.Type().Kind() != reflect.Ptr {
@@ -290,9 +515,11 @@ func (argp *Argp) AddArg(dst interface{}, name, description string) {
 	}
 	variable.Description = description
 	argp.vars = append(argp.vars, variable)
+	return variable
 }
 
-func (argp *Argp) AddRest(dst interface{}, name, description string) {
+// AddRest adds the remaining arguments, which can be a slice of any supported type. Set Min and Max of the returned variable to limit the number of values.
+func (argp *Argp) AddRest(dst interface{}, name, description string) *Var {
 	v := reflect.ValueOf(dst)
 	_, isCustom := dst.(Custom)
 	if !isCustom && v.Type().Kind() != reflect.Ptr {
@@ -307,272 +534,17 @@ func (argp *Argp) AddRest(dst interface{}, name, description string) {
 	variable.Index = -1
 	if argp.findRest() != nil {
 		panic("rest option already exists")
-	} else if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.String {
-		panic("rest option must be of type []string")
+	} else if v.Kind() != reflect.Slice || !isValidType(v.Type()) {
+		panic("rest option must be a slice")
 	}
 	variable.Rest = true
+	variable.Max = -1
 	if !isCustom {
 		variable.Default = v.Interface()
 	}
 	variable.Description = description
 	argp.vars = append(argp.vars, variable)
-}
-
-func wrapString(s string, cols int) (string, string) {
-	if len(s) <= cols {
-		return s, ""
-	}
-	minWidth := int(0.8*float64(cols) + 0.5)
-	for i := cols; minWidth <= i; i-- {
-		if s[i] == ' ' {
-			return s[:i], s[i+1:]
-		}
-	}
-	return s[:cols], s[cols:]
-}
-
-type optionHelp struct {
-	short, name, typ, desc string
-}
-
-func appendStructHelps(helps []optionHelp, root string, v reflect.Value) []optionHelp {
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Type().Field(i)
-		name := root + "."
-		if tagName := field.Tag.Get("name"); tagName != "" {
-			name += tagName
-		} else if tagShort := field.Tag.Get("short"); tagShort != "" {
-			name += tagShort
-		} else {
-			name += fromFieldname(field.Name)
-		}
-		if field.Type.Kind() == reflect.Struct {
-			helps = appendStructHelps(helps, name, v.Field(i))
-		} else {
-			if deflt := v.Field(i); !deflt.IsZero() {
-				val := fmt.Sprintf("%v", deflt)
-				if space := strings.IndexByte(val, ' '); space != -1 {
-					val = "'" + val + "'"
-				}
-				name += "=" + val
-			}
-			typ := TypeName(field.Type)
-			desc := field.Tag.Get("desc")
-			helps = append(helps, optionHelp{
-				short: "",
-				name:  name,
-				typ:   typ,
-				desc:  desc,
-			})
-		}
-	}
-	return helps
-}
-
-func getOptionHelps(vs []*Var) []optionHelp {
-	helps := []optionHelp{}
-	for _, v := range vs {
-		var val, typ string
-		if custom, ok := v.Value.Interface().(Custom); ok {
-			val, typ = custom.Help()
-		} else if v.Value.Kind() == reflect.Struct {
-			helps = appendStructHelps(helps, v.Name, v.Value)
-			continue
-		} else {
-			if v.Default != nil && !reflect.ValueOf(v.Default).IsZero() {
-				val = fmt.Sprint(v.Default)
-			}
-			typ = TypeName(v.Value.Type())
-		}
-
-		var short, name string
-		if v.Short != 0 {
-			short = string(v.Short)
-		}
-		name = v.Name
-		if val != "" {
-			if space := strings.IndexByte(val, ' '); space != -1 {
-				val = "'" + val + "'"
-			}
-			if name != "" {
-				name += "=" + val
-			} else {
-				short += "=" + val
-			}
-		}
-		helps = append(helps, optionHelp{
-			short: short,
-			name:  name,
-			typ:   typ,
-			desc:  v.Description,
-		})
-
-	}
-	return helps
-}
-
-// PrintHelp prints the help overview. This is automatically called when unknown or bad options are passed, but you can call this explicitly in other cases.
-func (argp *Argp) PrintHelp() {
-	_, cols, _ := TerminalSize()
-
-	base := argp.name
-	parent := argp.parent
-	for parent != nil {
-		base = parent.name + " " + base
-		parent = parent.parent
-	}
-
-	options := []*Var{}
-	arguments := []*Var{}
-	for _, v := range argp.vars {
-		if v.IsArgument() {
-			arguments = append(arguments, v)
-		} else {
-			options = append(options, v)
-		}
-	}
-	sort.Slice(options, sortOption(options))
-	sort.Slice(arguments, sortArgument(arguments))
-
-	args := ""
-	if 0 < len(options) {
-		args += " [options]"
-	}
-	if 0 < len(argp.cmds) {
-		fmt.Printf("Usage: %s%s [command] ...\n", base, args)
-	}
-	if 0 < len(arguments) {
-		for _, v := range arguments {
-			if !v.Rest {
-				args += " " + v.Name
-			}
-		}
-		if rest := argp.findRest(); rest != nil {
-			args += " " + rest.Name + "..."
-		}
-	}
-	if 0 < len(arguments) || len(argp.cmds) == 0 {
-		fmt.Printf("Usage: %s%s\n", base, args)
-	}
-
-	if 0 < len(options) {
-		optionHelps := getOptionHelps(options)
-
-		fmt.Printf("\nOptions:\n")
-		nMax := 0
-		for _, o := range optionHelps {
-			n := 0
-			if o.short != "" {
-				n += 4
-				if o.name != "" {
-					n += 4 + len(o.name)
-				}
-			} else if o.name != "" {
-				n += 8 + len(o.name)
-			}
-			if o.typ != "" {
-				n += 1 + len(o.typ)
-			}
-			n++ // whitespace before description
-			if nMax < n {
-				nMax = n
-			}
-		}
-		if 30 < nMax {
-			nMax = 30
-		} else if nMax < 10 {
-			nMax = 10
-		}
-		for _, o := range optionHelps {
-			n := 0
-			if o.short != "" {
-				fmt.Printf("  -%s, --%s", o.short, o.name)
-				n += 8 + len(o.name)
-			} else if o.name != "" {
-				fmt.Printf("      --%s", o.name)
-				n += 8 + len(o.name)
-			}
-			if o.typ != "" {
-				fmt.Printf(" %s", o.typ)
-				n += 1 + len(o.typ)
-			}
-			if nMax <= n {
-				fmt.Printf("\n")
-				n = 0
-			}
-			fmt.Printf("%s", strings.Repeat(" ", nMax-n))
-			if cols < 60 {
-				fmt.Printf("%s\n", o.desc)
-			} else if 0 < len(o.desc) {
-				n = nMax
-				for {
-					var s string
-					s, o.desc = wrapString(o.desc, cols-n)
-					fmt.Printf("%s\n", s)
-					if len(o.desc) == 0 {
-						break
-					}
-					fmt.Print(strings.Repeat(" ", n))
-				}
-			} else {
-				fmt.Printf("\n")
-			}
-		}
-	}
-
-	if 0 < len(argp.cmds) {
-		fmt.Printf("\nCommands:\n")
-		nMax := 0
-		cmds := []string{}
-		for cmd := range argp.cmds {
-			if nMax < 2+len(cmd) {
-				nMax = 2 + len(cmd)
-			}
-			cmds = append(cmds, cmd)
-		}
-		sort.Strings(cmds)
-
-		if 28 < nMax {
-			nMax = 28
-		} else if nMax < 10 {
-			nMax = 10
-		}
-		for _, cmd := range cmds {
-			sub := argp.cmds[cmd]
-			n := 2 + len(cmd)
-			fmt.Printf("  %s", cmd)
-			if nMax < n {
-				fmt.Printf("\n")
-				n = 0
-			}
-			fmt.Printf("%s  %s\n", strings.Repeat(" ", nMax-n), sub.Description)
-		}
-	}
-
-	if 0 < len(arguments) {
-		fmt.Printf("\nArguments:\n")
-		nMax := 0
-		for _, v := range arguments {
-			n := 2 + len(v.Name)
-			if nMax < n {
-				nMax = n
-			}
-		}
-		if 28 < nMax {
-			nMax = 28
-		} else if nMax < 10 {
-			nMax = 10
-		}
-		for _, v := range arguments {
-			n := 2 + len(v.Name)
-			fmt.Printf("  %s", v.Name)
-			if nMax < n {
-				fmt.Printf("\n")
-				n = 0
-			}
-			fmt.Printf("%s  %s\n", strings.Repeat(" ", nMax-n), v.Description)
-		}
-	}
+	return variable
 }
 
 // Parse parses the command line arguments. When the main command was instantiated with `NewCmd`, this command will exit.
@@ -585,6 +557,21 @@ func (argp *Argp) Parse() {
 	} else if cmd.help || cmd != argp && cmd.Cmd == nil {
 		cmd.PrintHelp()
 		os.Exit(0)
+	} else if cmd.helpAll {
+		cmd.PrintHelpAll()
+		os.Exit(0)
+	} else if cmd.showVersion {
+		if err := cmd.WriteVersion(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	} else if cmd.docs != "" {
+		if err := argp.GenerateDocs(cmd.docs); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
 	} else if cmd.Cmd != nil {
 		if len(rest) != 0 {
 			msg := "unknown arguments"
@@ -594,7 +581,7 @@ func (argp *Argp) Parse() {
 			fmt.Fprintf(os.Stderr, "%s: %v\n\n", msg, strings.Join(rest, " "))
 			cmd.PrintHelp()
 			os.Exit(2)
-		} else if err := cmd.Cmd.Run(); err != nil {
+		} else if err := cmd.run(); err != nil {
 			// Exit with status 2 on bad usage and with status 1 when we don't know the nature of the error.
 			if err == ShowUsage {
 				cmd.PrintHelp()
@@ -611,6 +598,24 @@ func (argp *Argp) Parse() {
 	}
 }
 
+// run runs the command and closes the files of its options and arguments.
+func (argp *Argp) run() error {
+	err := argp.Cmd.Run()
+	for _, v := range argp.vars {
+		var errClose error
+		switch f := v.Value.Interface().(type) {
+		case *InputFile:
+			errClose = f.Close()
+		case *OutputFile:
+			errClose = f.Close()
+		}
+		if err == nil {
+			err = errClose
+		}
+	}
+	return err
+}
+
 func (argp *Argp) findShort(short rune) *Var {
 	for _, v := range argp.vars {
 		if v.Short != 0 && v.Short == short {
@@ -655,13 +660,43 @@ func (argp *Argp) findRest() *Var {
 }
 
 func (argp *Argp) parse(args []string) (*Argp, []string, error) {
+	return argp.parseArgs(args, make([][]string, len(args)))
+}
+
+// parseArgs parses the arguments, where included holds for each argument the response files it was read from. Response files are expanded as they are parsed, so that the arguments after -- or where parsing stops are left untouched.
+func (argp *Argp) parseArgs(args []string, included [][]string) (*Argp, []string, error) {
+	expand := argp.hasResponseFiles()
+	for expand && 0 < len(args) && isResponseFile(args[0]) {
+		var err error
+		if args, included, err = expandResponseFile(args, included, 0); err != nil {
+			return argp, nil, err
+		}
+	}
+
 	// sub commands
 	if 0 < len(args) {
 		for cmd, sub := range argp.cmds {
 			if cmd == strings.ToLower(args[0]) {
-				return sub.parse(args[1:])
+				return sub.parseArgs(args[1:], included[1:])
 			}
 		}
+		if _, ok := argp.cmds["help"]; !ok && 0 < len(argp.cmds) && strings.ToLower(args[0]) == "help" {
+			// help [command...]
+			cmd := argp
+			for _, name := range args[1:] {
+				sub, ok := cmd.cmds[strings.ToLower(name)]
+				if !ok {
+					return cmd, nil, fmt.Errorf("unknown command %s", name)
+				}
+				cmd = sub
+			}
+			cmd.help = true
+			return cmd, nil, nil
+		}
+	}
+
+	if err := argp.checkStop(); err != nil {
+		return argp, nil, err
 	}
 
 	// set defaults
@@ -669,18 +704,37 @@ func (argp *Argp) parse(args []string) (*Argp, []string, error) {
 		if v.Default != nil {
 			if ok := v.Set(v.Default); !ok {
 				return argp, nil, fmt.Errorf("default: expected type %v", v.Value.Type())
+			} else if v.Path != "" {
+				if err := resolvePaths(v.Value, v.Path, "", false); err != nil {
+					return argp, nil, fmt.Errorf("default: %v", err)
+				}
 			}
 		}
 	}
 
 	rest := []string{}
+	unknown := map[int]bool{} // indices into rest of unknown options that are passed through
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
 		if arg == "--" {
 			rest = append(rest, args[i+1:]...)
 			break
+		} else if expand && isResponseFile(arg) {
+			var err error
+			if args, included, err = expandResponseFile(args, included, i); err != nil {
+				return argp, nil, err
+			}
+			i--
+			continue
 		}
-		if 1 < len(arg) && arg[0] == '-' {
+		if 1 < len(arg) && arg[0] == '-' && !argp.isNegativeNumber(arg, len(rest)-len(unknown)) {
+			// response file as value of the option
+			for expand && i+1 < len(args) && isResponseFile(args[i+1]) {
+				var err error
+				if args, included, err = expandResponseFile(args, included, i+1); err != nil {
+					return argp, nil, err
+				}
+			}
 			if 1 < len(arg) && arg[1] == '-' {
 				split := false
 				s := args[i+1:]
@@ -694,12 +748,27 @@ func (argp *Argp) parse(args []string) (*Argp, []string, error) {
 				}
 
 				v := argp.findName(name)
-				if v == nil {
+				if v == nil && argp.PassUnknown {
+					unknown[len(rest)] = true
+					rest = append(rest, arg)
+					continue
+				} else if v == nil {
 					return argp, nil, fmt.Errorf("unknown option --%s", name)
+				} else if v.Optional {
+					// value must be attached with an equal sign
+					val := v.Implicit
+					if idx := strings.IndexByte(arg, '='); idx != -1 {
+						val = arg[idx+1:]
+					}
+					if _, err := argp.scanOption(v, "--"+name, name, []string{val}, true); err != nil {
+						return argp, nil, err
+					}
+					v.isSet = true
+					continue
 				}
-				n, err := scanVar(v.Value, name, s)
+				n, err := argp.scanOption(v, "--"+name, name, s, split)
 				if err != nil {
-					return argp, nil, fmt.Errorf("option --%s: %v", name, err)
+					return argp, nil, err
 				} else {
 					i += n
 					if split {
@@ -713,77 +782,393 @@ func (argp *Argp) parse(args []string) (*Argp, []string, error) {
 					j += n
 
 					v := argp.findShort(name)
-					if v == nil {
+					if v == nil && argp.PassUnknown {
+						// pass the remainder of the combined short options
+						unknown[len(rest)] = true
+						rest = append(rest, "-"+arg[j-n:])
+						break
+					} else if v == nil {
 						return argp, nil, fmt.Errorf("unknown option -%c", name)
-					} else {
-						s := append([]string{arg[j:]}, args[i+1:]...)
-						hasEquals := j < len(arg) && arg[j] == '='
-						if hasEquals {
-							s[0] = s[0][1:]
-						}
-						valueGlued := 0 < len(s[0])
-						if !valueGlued {
-							s = s[1:]
+					} else if v.Optional {
+						// value must be attached
+						val := v.Implicit
+						if j < len(arg) {
+							val = strings.TrimPrefix(arg[j:], "=")
 						}
-						n, err := scanVar(v.Value, string(name), s)
-						if err != nil {
-							return argp, nil, fmt.Errorf("option -%c: %v", name, err)
-						} else if n == 0 {
-							continue // can be of the form -abc
+						if _, err := argp.scanOption(v, "-"+string(name), string(name), []string{val}, true); err != nil {
+							return argp, nil, err
 						}
-						if valueGlued {
-							n--
-						}
-						i += n
+						v.isSet = true
 						break
 					}
+
+					s := append([]string{arg[j:]}, args[i+1:]...)
+					hasEquals := j < len(arg) && arg[j] == '='
+					if hasEquals {
+						s[0] = s[0][1:]
+					}
+					valueGlued := 0 < len(s[0])
+					if !valueGlued {
+						s = s[1:]
+					}
+					n, err := argp.scanOption(v, "-"+string(name), string(name), s, valueGlued)
+					if err != nil {
+						return argp, nil, err
+					}
 					v.isSet = true
+					if n == 0 {
+						continue // can be of the form -abc
+					}
+					if valueGlued {
+						n--
+					}
+					i += n
+					break
 				}
 			}
 		} else if 0 < len(arg) {
 			rest = append(rest, arg)
+			if argp.isStrict() || argp.isStopped(rest, unknown) {
+				rest = append(rest, args[i+1:]...)
+				break
+			}
 		}
 	}
 
-	// indexed arguments
-	index := 0
-	for _, arg := range rest {
-		v := argp.findIndex(index)
-		if v == nil {
-			break
+	// indexed arguments, where variadic arguments leave enough values for the arguments that follow
+	positionals := []int{} // indices into rest of non-option arguments
+	for k := range rest {
+		if !unknown[k] {
+			positionals = append(positionals, k)
+		}
+	}
+	consumed := make([]bool, len(rest))
+	vars := []*Var{}
+	for index := 0; argp.findIndex(index) != nil; index++ {
+		vars = append(vars, argp.findIndex(index))
+	}
+	restVar := argp.findRest()
+	pos := 0
+	for j, v := range vars {
+		n := 1
+		if v.IsVariadic() {
+			reserve := 0
+			for _, w := range vars[j+1:] {
+				reserve += w.minValues()
+			}
+			if restVar != nil {
+				reserve += restVar.Min
+			}
+			if n = len(positionals) - pos - reserve; n < 0 {
+				n = 0
+			} else if v.Max != -1 && v.Max < n {
+				n = v.Max
+			}
+		} else if len(positionals) <= pos {
+			continue
+		} else {
+			// composite values can span multiple arguments
+			n = len(positionals) - pos
 		}
-		if _, err := scanVar(v.Value, "", []string{arg}); err != nil {
-			return argp, nil, fmt.Errorf("argument %d: %v", index, err)
+		vals := make([]string, n)
+		for i := range vals {
+			vals[i] = rest[positionals[pos+i]]
+		}
+		n, err := v.scanArguments(vals)
+		if err != nil {
+			return argp, nil, fmt.Errorf("argument %d: %v", v.Index, err)
+		}
+		for i := 0; i < n; i++ {
+			consumed[positionals[pos+i]] = true
 		}
 		v.isSet = true
-		index++
-	}
-	//for _, v := range argp.vars {
-	//	if v.Index != -1 && index <= v.Index && v.Default == nil {
-	//		name := v.Name
-	//		if name == "" {
-	//			name = strconv.Itoa(index)
-	//		}
-	//		return argp, nil, fmt.Errorf("argument %v is missing", name)
-	//	}
-	//}
-
-	// rest arguments
-	v := argp.findRest()
-	rest = rest[index:]
-	if v != nil {
-		v.Set(rest)
-		rest = rest[:0]
-		v.isSet = true
+		pos += n
+	}
+
+	// rest arguments, which include unknown options only for strings
+	remaining := []string{}
+	restVals := []string{}
+	for k, arg := range rest {
+		if consumed[k] {
+			continue
+		} else if restVar != nil && (!unknown[k] || restVar.Value.Type().Elem().Kind() == reflect.String) && (restVar.Max == -1 || len(restVals) < restVar.Max) {
+			restVals = append(restVals, arg)
+		} else {
+			remaining = append(remaining, arg)
+		}
+	}
+	if restVar != nil {
+		if _, err := restVar.scanArguments(restVals); err != nil {
+			name := restVar.Name
+			if name == "" {
+				name = "rest"
+			}
+			return argp, nil, fmt.Errorf("argument %s: %v", name, err)
+		}
+		restVar.isSet = true
+	}
+	return argp, remaining, nil
+}
+
+// isStopped returns true if the non-option arguments so far include all values of the argument marked stop. A variadic argument marked stop takes all remaining values, and is reached at its first value.
+func (argp *Argp) isStopped(rest []string, unknown map[int]bool) bool {
+	vars := []*Var{}
+	for index := 0; argp.findIndex(index) != nil; index++ {
+		vars = append(vars, argp.findIndex(index))
+		if vars[index].Stop {
+			break
+		}
+	}
+	if len(vars) == 0 || !vars[len(vars)-1].Stop {
+		return false
+	}
+
+	positionals := []string{}
+	for k, arg := range rest {
+		if !unknown[k] {
+			positionals = append(positionals, arg)
+		}
+	}
+
+	pos := 0
+	for _, v := range vars {
+		if v.IsVariadic() && v.Stop {
+			return pos < len(positionals)
+		}
+		m, t := 1, v.Value.Type()
+		if v.IsVariadic() {
+			m, t = v.Max, t.Elem() // fixed number of values, see checkStop
+		}
+		for ; 0 < m; m-- {
+			n := argumentSpan(t, positionals[pos:])
+			if n == 0 {
+				return false // values are incomplete
+			}
+			pos += n
+		}
+	}
+	return true
+}
+
+// checkStop returns an error if the argument marked stop follows a variadic argument with a variable number of values, since its position is not known while parsing options.
+func (argp *Argp) checkStop() error {
+	variable := -1
+	for index := 0; argp.findIndex(index) != nil; index++ {
+		v := argp.findIndex(index)
+		if v.Stop && variable != -1 {
+			return fmt.Errorf("argument %d: stop argument can not follow variadic argument %d with a variable number of values", index, variable)
+		} else if v.IsVariadic() && v.Min != v.Max && variable == -1 {
+			variable = index
+		}
+	}
+	return nil
+}
+
+// argumentSpan returns the number of values taken by an argument of the given type, which is more than one for composite values in brackets, or zero if the values are incomplete.
+func argumentSpan(t reflect.Type, vals []string) int {
+	if len(vals) == 0 {
+		return 0
+	}
+	switch t.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Map, reflect.Struct:
+		if 0 < len(vals[0]) && (vals[0][0] == '[' || vals[0][0] == '{') {
+			vals, _, _ = truncEnd(vals)
+			return len(vals)
+		}
+	}
+	return 1
+}
+
+// minValues returns the minimum number of values of an argument.
+func (v *Var) minValues() int {
+	if v.IsVariadic() {
+		return v.Min
+	}
+	return 1
+}
+
+// scanArguments parses the values of an argument and returns the number of values consumed, where a variadic argument takes all values and has one or more values per element.
+func (v *Var) scanArguments(vals []string) (int, error) {
+	if !v.IsVariadic() {
+		n, err := scanVar(v.Value, "", vals)
+		if err != nil {
+			return 0, err
+		} else if n == 0 {
+			n = 1 // boolean without value
+		}
+		if v.Path != "" {
+			if err := resolvePaths(v.Value, v.Path, "", true); err != nil {
+				return 0, err
+			}
+		}
+		return n, nil
+	}
+
+	slice := reflect.MakeSlice(v.Value.Type(), 0, len(vals))
+	for i := 0; i < len(vals); {
+		elem := reflect.New(v.Value.Type().Elem()).Elem()
+		n, err := scanVar(elem, "", vals[i:])
+		if err != nil {
+			return 0, fmt.Errorf("value %d: %v", slice.Len(), err)
+		} else if n == 0 {
+			n = 1
+		}
+		slice = reflect.Append(slice, elem)
+		i += n
+	}
+	if slice.Len() < v.Min {
+		if v.Min == 1 {
+			return 0, fmt.Errorf("expected at least 1 value")
+		}
+		return 0, fmt.Errorf("expected at least %d values", v.Min)
+	}
+	v.Value.Set(slice)
+	if v.Path != "" {
+		if err := resolvePaths(v.Value, v.Path, "", true); err != nil {
+			return 0, err
+		}
+	}
+	return len(vals), nil
+}
+
+// isResponseFile returns true for arguments such as @file.
+func isResponseFile(arg string) bool {
+	return 1 < len(arg) && arg[0] == '@'
+}
+
+// expandResponseFile replaces the argument at index i, such as @file, by the arguments in the file, which are split per line with the same quoting rules as default values. Lines starting with # are comments, and response files can include other response files which are expanded when parsed.
+func expandResponseFile(args []string, included [][]string, i int) ([]string, [][]string, error) {
+	filename := args[i][1:]
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("response file %v: %v", filename, err)
+	} else if containsString(included[i], abs) {
+		return nil, nil, fmt.Errorf("response file %v: includes itself", filename)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("response file %v: %v", filename, err)
+	}
+
+	fileArgs := []string{}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if len(line) == 0 || line[0] == '#' {
+			// empty line or comment
+			continue
+		}
+		fileArgs = append(fileArgs, splitArguments(line)...)
+	}
+	if err := s.Err(); err != nil {
+		return nil, nil, fmt.Errorf("response file %v: %v", filename, err)
+	}
+
+	chain := append(included[i][:len(included[i]):len(included[i])], abs)
+	expanded := append(append(append([]string{}, args[:i]...), fileArgs...), args[i+1:]...)
+	expandedIncluded := append([][]string{}, included[:i]...)
+	for range fileArgs {
+		expandedIncluded = append(expandedIncluded, chain)
+	}
+	expandedIncluded = append(expandedIncluded, included[i+1:]...)
+	return expanded, expandedIncluded, nil
+}
+
+// scanOption parses the value of an option, such as --name or -n, and warns when a secret is given on the command line. A counting option only takes a separate negative value if it is not an option.
+func (argp *Argp) scanOption(v *Var, option, name string, s []string, attached bool) (int, error) {
+	if _, ok := v.Value.Interface().(Count); ok && !attached && 0 < len(s) && strings.HasPrefix(s[0], "-") && (!argp.NegativeNumbers || argp.hasDigitShort()) {
+		s = nil
+	}
+	n, indirect, err := v.scan(name, s)
+	if err != nil {
+		return 0, fmt.Errorf("option %s: %v", option, err)
+	} else if v.Secret && !indirect {
+		argp.warn(fmt.Sprintf("option %s is a secret that may be visible to other users, use file:, env:, or - instead", option))
+	}
+	return n, nil
+}
+
+// warn prints a warning using the Error logger of the root command, or to standard error if not set.
+func (argp *Argp) warn(msg string) {
+	root := argp
+	for root.parent != nil {
+		root = root.parent
+	}
+	if root.Error != nil {
+		root.Error.Println("WARNING: " + msg)
+	} else {
+		fmt.Fprintf(os.Stderr, "WARNING: %v\n", msg)
+	}
+}
+
+// hasResponseFiles returns true if response files are expanded by the command or a parent.
+func (argp *Argp) hasResponseFiles() bool {
+	for cmd := argp; cmd != nil; cmd = cmd.parent {
+		if cmd.ResponseFiles {
+			return true
+		}
+	}
+	return false
+}
+
+// isStrict returns true if option parsing stops at the first non-option argument.
+func (argp *Argp) isStrict() bool {
+	for cmd := argp; cmd != nil; cmd = cmd.parent {
+		if cmd.Strict {
+			return true
+		}
 	}
-	return argp, rest, nil
+	return os.Getenv("POSIXLY_CORRECT") != ""
+}
+
+// isNegativeNumber returns true if the argument is a negative number and not an option, which is when no short option is a digit or when the argument at the given index is numeric.
+func (argp *Argp) isNegativeNumber(arg string, index int) bool {
+	if !argp.NegativeNumbers || !isNegativeNumber(arg) {
+		return false
+	}
+	for _, v := range argp.vars {
+		if v.Index == index && !v.IsVariadic() && isNumericType(v.Value.Type()) {
+			return true
+		} else if v.IsVariadic() && (v.Rest || v.Index <= index) && isNumericType(v.Value.Type().Elem()) {
+			return true
+		}
+	}
+	return !argp.hasDigitShort()
+}
+
+// hasDigitShort returns true if a short option is a digit, such as -1.
+func (argp *Argp) hasDigitShort() bool {
+	for _, v := range argp.vars {
+		if '0' <= v.Short && v.Short <= '9' {
+			return true
+		}
+	}
+	return false
 }
 
 // scanVar parses a slice of strings into the given value.
 func scanVar(v reflect.Value, name string, s []string) (int, error) {
-	if scanner, ok := v.Interface().(Custom); ok {
-		// implements Custom
-		return scanner.Scan(name, s)
+	return walkVar(v, name, func(v reflect.Value, name string) (int, error) {
+		if scanner, ok := v.Interface().(Custom); ok {
+			// implements Custom
+			return scanner.Scan(name, s)
+		}
+		n, err := scanValue(v, s)
+		if err != nil && v.Kind() == reflect.Bool {
+			v.SetBool(true)
+			return 0, nil
+		}
+		return n, err
+	})
+}
+
+// maxSliceExpansion is the maximum number of elements that a slice is expanded by past its end when setting an index.
+const maxSliceExpansion = 1024
+
+// walkVar calls f with the value at the path of the given name, such as a.b[2], where slices are expanded and maps are allocated as needed. A Custom value is passed with the remainder of the name.
+func walkVar(v reflect.Value, name string, f func(reflect.Value, string) (int, error)) (int, error) {
+	if _, ok := v.Interface().(Custom); ok {
+		return f(v, name)
 	}
 
 	if i := strings.IndexAny(name, ".["); i != -1 {
@@ -817,11 +1202,15 @@ func scanVar(v reflect.Value, name string, s []string) (int, error) {
 			var index int
 			if _, err := scanValue(reflect.ValueOf(&index).Elem(), []string{name}); err != nil {
 				return 0, fmt.Errorf("%v index %v: %v", typ, name, err)
-			} else if v.Kind() == reflect.Slice && v.IsNil() || index < 0 || v.Len() <= index {
-				// TODO: slice expand range?
+			} else if index < 0 || v.Kind() == reflect.Array && v.Len() <= index || maxSliceExpansion < index-v.Len() {
 				return 0, fmt.Errorf("%v index %v: out of range", typ, index)
+			} else if v.Len() <= index {
+				// expand slice
+				slice := reflect.MakeSlice(v.Type(), index+1, index+1)
+				reflect.Copy(slice, v)
+				v.Set(slice)
 			}
-			return scanVar(v.Index(index), rest, s)
+			return walkVar(v.Index(index), rest, f)
 		case reflect.Map:
 			key := reflect.New(v.Type().Key()).Elem()
 			if _, err := scanValue(key, []string{name}); err != nil {
@@ -830,41 +1219,36 @@ func scanVar(v reflect.Value, name string, s []string) (int, error) {
 				v.Set(reflect.MakeMap(v.Type()))
 			}
 			field := reflect.New(v.Type().Elem()).Elem()
-			n, err := scanVar(field, rest, s)
+			if prev := v.MapIndex(key); prev.IsValid() {
+				// keep other fields or elements of the existing value
+				field.Set(prev)
+			}
+			n, err := walkVar(field, rest, f)
 			if err == nil {
 				v.SetMapIndex(key, field)
 			}
 			return n, err
 		case reflect.Struct:
-			t := v.Type()
-			found := false
-			for i := 0; i < t.NumField(); i++ {
-				if t.Field(i).Tag.Get("name") == name || t.Field(i).Tag.Get("short") == name || fromFieldname(t.Field(i).Name) == name {
-					name = t.Field(i).Name
-					found = true
-					break
-				}
+			field, ok := findField(v.Type(), name)
+			if !ok {
+				return 0, fmt.Errorf("struct field: missing field %v in struct", toFieldname(name))
 			}
-			if !found {
-				name = toFieldname(name)
-			}
-			field := v.FieldByName(name)
-			zero := reflect.Value{}
-			if field == zero {
-				return 0, fmt.Errorf("struct field: missing field %v in struct", name)
-			}
-			return scanVar(field, rest, s)
+			return walkVar(v.FieldByIndex(field.Index), rest, f)
 		default:
 			return 0, fmt.Errorf("unexpected %v in name %v", string(origName[i]), origName)
 		}
 	}
 
-	n, err := scanValue(v, s)
-	if err != nil && v.Kind() == reflect.Bool {
-		v.SetBool(true)
-		return 0, nil
+	return f(v, name)
+}
+
+func containsString(ss []string, s string) bool {
+	for _, item := range ss {
+		if item == s {
+			return true
+		}
 	}
-	return n, err
+	return false
 }
 
 func indexByte(s string, c byte) int {
@@ -915,6 +1299,10 @@ func truncEnd(s []string) ([]string, []string, bool) {
 	return nil, s, false // no closing bracket found
 }
 
+var durationType = reflect.TypeOf(time.Duration(0))
+var sizeType = reflect.TypeOf(Size(0))
+var secretType = reflect.TypeOf(Secret(""))
+
 func scanValue(v reflect.Value, s []string) (int, error) {
 	if len(s) == 0 {
 		if v.Kind() == reflect.String {
@@ -925,6 +1313,30 @@ func scanValue(v reflect.Value, s []string) (int, error) {
 	}
 
 	n := 0
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s[0])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%v'", s[0])
+		}
+		v.SetInt(int64(d))
+		return 1, nil
+	} else if isBytesType(v.Type()) && 0 < len(s[0]) && s[0][0] != '[' {
+		// hex or base64, brackets are used for a list of bytes
+		b, err := decodeBytes(s[0], "hex")
+		if err != nil {
+			return 0, err
+		} else if err := setBytes(v, b); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	} else if v.Type() == sizeType {
+		i, err := parseSize(s[0])
+		if err != nil {
+			return 0, err
+		}
+		v.SetInt(i)
+		return 1, nil
+	}
 	switch v.Kind() {
 	case reflect.String:
 		v.SetString(s[0])
@@ -937,14 +1349,14 @@ func scanValue(v reflect.Value, s []string) (int, error) {
 		v.SetBool(i)
 		n++
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		i, err := strconv.ParseInt(s[0], 10, 64)
+		i, err := parseInt(s[0])
 		if err != nil {
 			return 0, fmt.Errorf("invalid integer '%v'", s[0])
 		}
 		v.SetInt(i)
 		n++
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		i, err := strconv.ParseUint(s[0], 10, 64)
+		i, err := parseUint(s[0])
 		if err != nil {
 			return 0, fmt.Errorf("invalid positive integer '%v'", s[0])
 		}
@@ -1157,6 +1569,52 @@ func scanValue(v reflect.Value, s []string) (int, error) {
 			s[len(s)-1] = s[len(s)-1][:len(s[len(s)-1])-1]
 		}
 
+		if isNamedFields(v.Type(), s) {
+			// named fields, omitted fields keep their value
+			for 0 < len(s) {
+				// consume name
+				for 0 < len(s) && len(s[0]) == 0 {
+					s = s[1:]
+				}
+				if len(s) == 0 {
+					break
+				}
+				idx := indexByte(s[0], ':')
+				if idx == -1 {
+					return 0, fmt.Errorf("struct field %v: missing colon", s[0])
+				}
+				name := s[0][:idx]
+				field, ok := findField(v.Type(), name)
+				if !ok {
+					return 0, fmt.Errorf("struct field: missing field %v in struct", toFieldname(name))
+				}
+				if idx+1 == len(s[0]) {
+					s = s[1:]
+				} else {
+					s[0] = s[0][idx+1:]
+				}
+
+				// consume value
+				var sVal []string
+				if len(s) == 0 {
+					// empty value after colon
+					sVal = []string{""}
+				} else if s[0][0] == '{' || s[0][0] == '[' {
+					sVal, s, split = truncEnd(s)
+					if sVal == nil || split {
+						return 0, fmt.Errorf("struct field %v: invalid value", field.Name)
+					}
+				} else {
+					sVal = []string{s[0]}
+					s = s[1:]
+				}
+				if _, err := scanValue(v.FieldByIndex(field.Index), sVal); err != nil {
+					return 0, fmt.Errorf("struct field %v: %v", field.Name, err)
+				}
+			}
+			break
+		}
+
 		j := 0
 		for j < v.NumField() {
 			// consume value
@@ -1193,6 +1651,103 @@ func scanValue(v reflect.Value, s []string) (int, error) {
 	return n, nil
 }
 
+// findField returns the struct field by its name or short tag, or by its field name.
+func findField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("name") == name || t.Field(i).Tag.Get("short") == name || fromFieldname(t.Field(i).Name) == name {
+			return t.Field(i), true
+		}
+	}
+	return t.FieldByName(toFieldname(name))
+}
+
+// isNamedFields returns true if the struct values start with a field name followed by a colon, such as {name:value}.
+func isNamedFields(t reflect.Type, s []string) bool {
+	for 0 < len(s) && len(s[0]) == 0 {
+		s = s[1:]
+	}
+	if len(s) == 0 {
+		return false
+	}
+	idx := indexByte(s[0], ':')
+	if idx < 1 || strings.ContainsAny(s[0][:idx], "{[") {
+		return false
+	}
+	_, ok := findField(t, s[0][:idx])
+	return ok
+}
+
+// parseInt parses an integer with an optional base prefix 0b, 0o, or 0x, and underscores between digits. Unlike Go syntax, a leading zero does not mean octal.
+func parseInt(s string) (int64, error) {
+	if isLeadingZero(s) {
+		return strconv.ParseInt(strings.ReplaceAll(s, "_", ""), 10, 64)
+	}
+	return strconv.ParseInt(s, 0, 64)
+}
+
+// parseUint parses a positive integer like parseInt.
+func parseUint(s string) (uint64, error) {
+	if isLeadingZero(s) {
+		return strconv.ParseUint(strings.ReplaceAll(s, "_", ""), 10, 64)
+	}
+	return strconv.ParseUint(s, 0, 64)
+}
+
+// isLeadingZero returns true if s starts with a zero followed by a digit or underscore, optionally after its sign.
+func isLeadingZero(s string) bool {
+	if 0 < len(s) && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+	return 1 < len(s) && s[0] == '0' && ('0' <= s[1] && s[1] <= '9' || s[1] == '_')
+}
+
+// isNegativeNumber returns true if s is a negative integer or floating-point number.
+func isNegativeNumber(s string) bool {
+	if len(s) < 2 || s[0] != '-' || (s[1] < '0' || '9' < s[1]) && s[1] != '.' {
+		return false
+	} else if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseInt(s, 0, 64)
+	return err == nil
+}
+
+// isNumericType returns true for integer and floating-point types.
+func isNumericType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// parseNargs parses the number of values of a variadic argument, such as 2, 1.. or 2..5, and returns -1 as the maximum when unlimited.
+func parseNargs(s string) (int, int, error) {
+	min, max := 0, -1
+	smin, smax := s, ""
+	idx := strings.Index(s, "..")
+	if idx != -1 {
+		smin, smax = s[:idx], s[idx+2:]
+	}
+	var err error
+	if smin != "" {
+		if min, err = strconv.Atoi(smin); err != nil || min < 0 {
+			return 0, 0, fmt.Errorf("invalid nargs: %v", s)
+		}
+	}
+	if idx == -1 {
+		max = min
+	} else if smax != "" {
+		if max, err = strconv.Atoi(smax); err != nil || max < min {
+			return 0, 0, fmt.Errorf("invalid nargs: %v", s)
+		}
+	}
+	if max == 0 {
+		return 0, 0, fmt.Errorf("invalid nargs: %v", s)
+	}
+	return min, max, nil
+}
+
 // isValidName returns true if the short or long option name is valid.
 func isValidName(s string) bool {
 	for i, r := range s {
@@ -1203,9 +1758,11 @@ func isValidName(s string) bool {
 	return true
 }
 
-// isValidType returns true if the destination variable type is supported. Either it implements the Custom interface, or is a valid base type.
+var customType = reflect.TypeOf((*Custom)(nil)).Elem()
+
+// isValidType returns true if the destination variable type is supported. Either it or its pointer implements the Custom interface, or is a valid base type.
 func isValidType(t reflect.Type) bool {
-	if t.Implements(reflect.TypeOf((*Custom)(nil)).Elem()) {
+	if t.Implements(customType) || reflect.PtrTo(t).Implements(customType) {
 		// implements Custom
 		return true
 	}
@@ -1234,24 +1791,44 @@ func isValidBaseType(t reflect.Type) bool {
 // TypeName returns the type's name.
 func TypeName(t reflect.Type) string {
 	k := t.Kind()
-	if k == reflect.Int || k == reflect.Int8 || k == reflect.Int16 || k == reflect.Int32 || k == reflect.Int64 {
+	if t == durationType {
+		return "duration"
+	} else if t == sizeType {
+		return "size"
+	} else if t == secretType {
+		return "secret"
+	} else if isBytesType(t) {
+		return "hex"
+	} else if k == reflect.Int || k == reflect.Int8 || k == reflect.Int16 || k == reflect.Int32 || k == reflect.Int64 {
 		return "int"
 	} else if k == reflect.Uint || k == reflect.Uint8 || k == reflect.Uint16 || k == reflect.Uint32 || k == reflect.Uint64 {
 		return "uint"
 	} else if k == reflect.Float32 || k == reflect.Float64 {
 		return "float"
 	} else if k == reflect.Array || k == reflect.Slice {
-		return "[]" + TypeName(t.Elem())
+		return "[]" + elemTypeName(t.Elem())
 	} else if k == reflect.Map {
-		return "map[" + TypeName(t.Key()) + "]" + TypeName(t.Elem())
+		return "{" + elemTypeName(t.Key()) + ":" + elemTypeName(t.Elem()) + " ...}"
 	} else if k == reflect.String {
 		return "string"
 	} else if k == reflect.Struct {
-		return "struct"
+		fields := make([]string, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			fields[i] = elemTypeName(t.Field(i).Type)
+		}
+		return "{" + strings.Join(fields, " ") + "}"
 	}
 	return ""
 }
 
+// elemTypeName returns the type's name for elements of composite types, where booleans are not omitted.
+func elemTypeName(t reflect.Type) string {
+	if t.Kind() == reflect.Bool {
+		return "bool"
+	}
+	return TypeName(t)
+}
+
 // sortOption sorts options by short and then name.
 func sortOption(vars []*Var) func(int, int) bool {
 	return func(i, j int) bool {
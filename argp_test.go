@@ -1,10 +1,17 @@
 package argp
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
+	"text/template"
+	"time"
 
 	"github.com/tdewolff/test"
 )
@@ -299,6 +306,74 @@ func TestArgp(t *testing.T) {
 	}
 }
 
+type SEmbedDB struct {
+	Host string `short:"H" default:"localhost"`
+	Port int    `default:"5432" desc:"Port number"`
+}
+
+type SEmbedCache struct {
+	Size int `default:"64"`
+}
+
+type SEmbed struct {
+	DB     SEmbedDB    `embed:"" prefix:"db-" desc:"Database"`
+	Cache  SEmbedCache `embed:"" prefix:"cache-"`
+	Output string      `short:"o"`
+}
+
+func (_ *SEmbed) Run() error {
+	return nil
+}
+
+func TestArgpEmbed(t *testing.T) {
+	tests := []struct {
+		args []string
+		s    SEmbed
+	}{
+		{[]string{}, SEmbed{DB: SEmbedDB{"localhost", 5432}, Cache: SEmbedCache{64}}},
+		{[]string{"--db-host", "db", "--cache-size", "128"}, SEmbed{DB: SEmbedDB{"db", 5432}, Cache: SEmbedCache{128}}},
+		{[]string{"-H", "db", "--db-port=1234", "-o", "out"}, SEmbed{DB: SEmbedDB{"db", 1234}, Cache: SEmbedCache{64}, Output: "out"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v", tt.args), func(t *testing.T) {
+			s := SEmbed{}
+			argp := NewCmd(&s, "description")
+			_, rest, err := argp.parse(tt.args)
+			test.Error(t, err)
+			test.T(t, s, tt.s)
+			test.T(t, strings.Join(rest, " "), "")
+		})
+	}
+
+	s := SEmbed{}
+	argp := NewCmd(&s, "description")
+	test.T(t, argp.findName("db-port").Group, "Database")
+	test.T(t, argp.findName("cache-size").Group, "")
+}
+
+type SGroups struct {
+	Host    string   `group:"Network"`
+	DB      SEmbedDB `embed:"" prefix:"db-" group:"Storage" desc:"Database"`
+	Verbose bool
+}
+
+func (_ *SGroups) Run() error {
+	return nil
+}
+
+func TestArgpGroups(t *testing.T) {
+	s := SGroups{}
+	argp := NewCmd(&s, "description")
+	test.T(t, argp.findName("host").Group, "Network")
+	test.T(t, argp.findName("db-host").Group, "Storage")
+	test.T(t, argp.findName("verbose").Group, "")
+
+	var port int
+	argp.AddOpt(&port, "p", "port", "description").Group = "Network"
+	test.T(t, argp.findName("port").Group, "Network")
+}
+
 func TestArgpAdd(t *testing.T) {
 	o := int64(4)
 	var v bool
@@ -329,6 +404,623 @@ func TestArgpAddRest(t *testing.T) {
 	test.T(t, rest, []string{})
 }
 
+type SVariadic struct {
+	Src  []string `index:"0" nargs:"1.."`
+	Dst  string   `index:"1"`
+	Nums []int    `index:"*" nargs:"..2"`
+}
+
+func (_ *SVariadic) Run() error {
+	return nil
+}
+
+func TestArgpVariadic(t *testing.T) {
+	s := SVariadic{}
+	argp := NewCmd(&s, "description")
+	argp.name = "test"
+	_, rest, err := argp.parse([]string{"a", "b", "c"})
+	test.Error(t, err)
+	test.T(t, s.Src, []string{"a", "b"})
+	test.T(t, s.Dst, "c")
+	test.T(t, s.Nums, []int{})
+	test.T(t, rest, []string{})
+	test.T(t, argp.Help().Usage, []string{"test [options] src... dst nums{0..2}"})
+
+	_, _, err = argp.parse([]string{})
+	test.T(t, err, fmt.Errorf("argument 0: expected at least 1 value"))
+
+	var in, out string
+	var nums []int
+	var durations []time.Duration
+	argp = New("description")
+	argp.name = "test"
+	argp.AddArg(&in, "in", "description")
+	v := argp.AddArg(&nums, "nums", "description")
+	v.Min, v.Max = 2, 3
+	argp.AddArg(&out, "out", "description")
+	argp.AddRest(&durations, "durations", "description")
+	_, rest, err = argp.parse([]string{"x", "1", "-2", "3", "4", "5s", "1m"})
+	test.Error(t, err)
+	test.T(t, in, "x")
+	test.T(t, nums, []int{1, -2, 3})
+	test.T(t, out, "4")
+	test.T(t, durations, []time.Duration{5 * time.Second, time.Minute})
+	test.T(t, rest, []string{})
+	test.T(t, argp.Help().Usage, []string{"test [options] in nums{2..3} out [durations...]"})
+
+	_, _, err = argp.parse([]string{"x", "1", "2", "y"})
+	test.Error(t, err)
+	test.T(t, nums, []int{1, 2})
+	test.T(t, out, "y")
+	test.T(t, durations, []time.Duration{})
+
+	_, _, err = argp.parse([]string{"x", "1", "y"})
+	test.T(t, err, fmt.Errorf("argument 1: expected at least 2 values"))
+
+	_, _, err = argp.parse([]string{"x", "1", "2", "3", "y", "5"})
+	test.T(t, err, fmt.Errorf("argument durations: value 0: invalid duration '5'"))
+}
+
+func TestArgpCompositeArguments(t *testing.T) {
+	var ints []int
+	var point struct {
+		X, Y int
+	}
+	var points [][2]float64
+	argp := New("description")
+	argp.AddArg(&ints, "", "description")
+	argp.AddArg(&point, "", "description")
+	v := argp.AddArg(&points, "", "description")
+	v.Min, v.Max = 1, -1
+
+	_, rest, err := argp.parse([]string{"[1", "2", "3]", "{4", "5}", "[1", "2]", "3,4"})
+	test.Error(t, err)
+	test.T(t, ints, []int{1, 2, 3})
+	test.T(t, point.X, 4)
+	test.T(t, point.Y, 5)
+	test.T(t, points, [][2]float64{{1, 2}, {3, 4}})
+	test.T(t, rest, []string{})
+
+	_, _, err = argp.parse([]string{"[1", "2", "{3", "4}", "[5,6]"})
+	test.T(t, err, fmt.Errorf("argument 0: invalid slice"))
+}
+
+func TestArgpResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args.txt")
+	nested := filepath.Join(dir, "nested.txt")
+	cycle := filepath.Join(dir, "cycle.txt")
+	test.Error(t, os.WriteFile(args, []byte("# comment\n-v\n--output 'out file.txt'\n@"+nested+"\n"), 0644))
+	test.Error(t, os.WriteFile(nested, []byte("  input.txt\n\n  # indented comment\n"), 0644))
+	test.Error(t, os.WriteFile(cycle, []byte("@"+cycle+"\n"), 0644))
+
+	var verbose bool
+	var output string
+	argp := New("description")
+	argp.AddOpt(&verbose, "v", "", "description")
+	argp.AddOpt(&output, "o", "output", "description")
+	_, rest, err := argp.parse([]string{"@" + args, "--", "@" + args})
+	test.Error(t, err)
+	test.T(t, rest, []string{"@" + args, "@" + args})
+
+	argp.ResponseFiles = true
+	_, rest, err = argp.parse([]string{"@" + args, "--", "@" + args})
+	test.Error(t, err)
+	test.T(t, verbose, true)
+	test.T(t, output, "out file.txt")
+	test.T(t, rest, []string{"input.txt", "@" + args})
+
+	_, _, err = argp.parse([]string{"@" + cycle})
+	test.T(t, err, fmt.Errorf("response file %v: includes itself", cycle))
+
+	// not expanded after parsing stops
+	argp.Strict = true
+	_, rest, err = argp.parse([]string{"-v", "cmd", "@" + args})
+	test.Error(t, err)
+	test.T(t, rest, []string{"cmd", "@" + args})
+	argp.Strict = false
+
+	sStrict := SStrict{}
+	argp = NewCmd(&sStrict, "description")
+	argp.ResponseFiles = true
+	_, _, err = argp.parse([]string{"ls", "@" + args})
+	test.Error(t, err)
+	test.T(t, sStrict, SStrict{false, "ls", []string{"@" + args}})
+
+	_, _, err = argp.parse([]string{"@" + filepath.Join(dir, "missing.txt")})
+	test.That(t, err != nil)
+}
+
+type SIndirect struct {
+	Password string         `indirect:""`
+	Emails   []string       `indirect:""`
+	Limits   map[string]int `indirect:""`
+	Plain    string
+}
+
+func (_ *SIndirect) Run() error {
+	return nil
+}
+
+func TestArgpIndirect(t *testing.T) {
+	dir := t.TempDir()
+	emails := filepath.Join(dir, "emails.txt")
+	limits := filepath.Join(dir, "limits.txt")
+	test.Error(t, os.WriteFile(emails, []byte("a@example.com\n\n  b@example.com\n"), 0644))
+	test.Error(t, os.WriteFile(limits, []byte("cpu = 2\nmemory=512\n"), 0644))
+	t.Setenv("ARGP_PASSWORD", "secret")
+
+	s := SIndirect{}
+	argp := NewCmd(&s, "description")
+	_, _, err := argp.parse([]string{"--password", "env:ARGP_PASSWORD", "--emails", "file:" + emails, "--limits=file:" + limits, "--plain", "env:ARGP_PASSWORD"})
+	test.Error(t, err)
+	test.T(t, s.Password, "secret")
+	test.T(t, s.Emails, []string{"a@example.com", "b@example.com"})
+	test.T(t, s.Limits, map[string]int{"cpu": 2, "memory": 512})
+	test.T(t, s.Plain, "env:ARGP_PASSWORD")
+
+	_, _, err = argp.parse([]string{"--password", "env:ARGP_MISSING"})
+	test.T(t, err, fmt.Errorf("option --password: env:ARGP_MISSING: environment variable ARGP_MISSING is not set"))
+
+	AddIndirection("upper:", func(s string) (string, error) {
+		return strings.ToUpper(s), nil
+	})
+	defer delete(indirections, "upper:")
+	_, _, err = argp.parse([]string{"--password", "upper:secret"})
+	test.Error(t, err)
+	test.T(t, s.Password, "SECRET")
+}
+
+type SSetHost struct {
+	Name string
+	Port int
+}
+
+type SSet struct {
+	Hosts  []SSetHost
+	Labels map[string][]string
+	Certs  []string
+}
+
+func (_ *SSet) Run() error {
+	return nil
+}
+
+func TestArgpSet(t *testing.T) {
+	dir := t.TempDir()
+	certs := filepath.Join(dir, "certs.txt")
+	test.Error(t, os.WriteFile(certs, []byte("a.pem\nb.pem\n"), 0644))
+
+	s := SSet{}
+	argp := NewCmd(&s, "description")
+	argp.AddOpt(Set{argp}, "", "set", "description")
+	argp.AddOpt(SetFile{argp}, "", "set-file", "description")
+	_, _, err := argp.parse([]string{"--set", "hosts[1].name=b", "--set", "hosts[1].port=80", "--set", "labels.x[0]=1", "--set=labels.x[2]=3", "--set-file", "certs=" + certs})
+	test.Error(t, err)
+	test.T(t, s.Hosts, []SSetHost{{}, {"b", 80}})
+	test.T(t, s.Labels, map[string][]string{"x": {"1", "", "3"}})
+	test.T(t, s.Certs, []string{"a.pem", "b.pem"})
+	test.T(t, argp.IsSet("hosts"), true)
+
+	s.Hosts = nil
+	_, _, err = argp.parse([]string{"--set", "hosts=[{a 1} {b 2}]"})
+	test.Error(t, err)
+	test.T(t, s.Hosts, []SSetHost{{"a", 1}, {"b", 2}})
+
+	s.Labels = nil
+	_, _, err = argp.parse([]string{"--set-file", "labels.x=" + certs, "--set-file", "hosts[2].name=" + certs})
+	test.Error(t, err)
+	test.T(t, s.Labels, map[string][]string{"x": {"a.pem", "b.pem"}})
+	test.T(t, s.Hosts[2].Name, "a.pem\nb.pem")
+
+	_, _, err = argp.parse([]string{"--set", "certs[9223372036854775807]=x"})
+	test.T(t, err, fmt.Errorf("option --set: certs[9223372036854775807]: slice index 9223372036854775807: out of range"))
+	_, _, err = argp.parse([]string{"--set", "certs[1000000000]=x"})
+	test.T(t, err, fmt.Errorf("option --set: certs[1000000000]: slice index 1000000000: out of range"))
+
+	_, _, err = argp.parse([]string{"--set", "missing.x=1"})
+	test.T(t, err, fmt.Errorf("option --set: unknown option missing.x"))
+	_, _, err = argp.parse([]string{"--set", "hosts"})
+	test.T(t, err, fmt.Errorf("option --set: expected path=value"))
+}
+
+type SMerge struct {
+	Labels map[string]int `short:"l" merge:"" default:"{a:1}"`
+	Server SSetHost       `merge:""`
+}
+
+func (_ *SMerge) Run() error {
+	return nil
+}
+
+func TestArgpMerge(t *testing.T) {
+	s := SMerge{}
+	argp := NewCmd(&s, "description")
+	test.T(t, s.Labels, map[string]int{"a": 1})
+
+	_, _, err := argp.parse([]string{"-l", "b=2", "--labels", "{c:3", "a:4}", "--server", "port=80", "--server", "name=host"})
+	test.Error(t, err)
+	test.T(t, s.Labels, map[string]int{"a": 4, "b": 2, "c": 3})
+	test.T(t, s.Server, SSetHost{"host", 80})
+
+	// set by path
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.toml")
+	test.Error(t, os.WriteFile(config, []byte("[labels]\nz = 3\n"), 0644))
+	argp.AddOpt(Set{argp}, "", "set", "description")
+	argp.AddOpt(&Config{argp, ""}, "", "config", "description")
+	s.Labels = nil
+	_, _, err = argp.parse([]string{"--labels.x", "1", "--set", "labels.y=2", "--config", config, "--server.port", "8080"})
+	test.Error(t, err)
+	test.T(t, s.Labels, map[string]int{"x": 1, "y": 2, "z": 3})
+	test.T(t, s.Server.Port, 8080)
+
+	var labels map[string]string
+	argp = New("description")
+	argp.AddOpt(Merge{&labels}, "", "label", "description")
+	_, _, err = argp.parse([]string{"--label", "x=1", "--label=y=2"})
+	test.Error(t, err)
+	test.T(t, labels, map[string]string{"x": "1", "y": "2"})
+
+	_, _, err = argp.parse([]string{"--label", "x"})
+	test.T(t, err, fmt.Errorf("option --label: expected key=value or {key:value ...}"))
+}
+
+func TestArgpNamedFields(t *testing.T) {
+	type Struct struct {
+		S string
+		I int `name:"num" short:"n"`
+		B [2]bool
+	}
+	v := Struct{"default", 42, [2]bool{}}
+	argp := New("description")
+	argp.AddOpt(&v, "", "struct", "description")
+
+	_, _, err := argp.parse([]string{"--struct", "{b:[0", "1]", "n:5}"})
+	test.Error(t, err)
+	test.T(t, v, Struct{"default", 5, [2]bool{false, true}})
+
+	_, _, err = argp.parse([]string{"--struct", "{s:", "string", "num:7}"})
+	test.Error(t, err)
+	test.T(t, v, Struct{"string", 7, [2]bool{}})
+
+	_, _, err = argp.parse([]string{"--struct", "{s:x", "y}"})
+	test.T(t, err, fmt.Errorf("option --struct: struct field y: missing colon"))
+
+	_, _, err = argp.parse([]string{"--struct", "{s:x", "z:1}"})
+	test.T(t, err, fmt.Errorf("option --struct: struct field: missing field Z in struct"))
+
+	// positional values that contain a colon
+	_, _, err = argp.parse([]string{"--struct", "{http://host", "1", "[1", "0]}"})
+	test.Error(t, err)
+	test.T(t, v, Struct{"http://host", 1, [2]bool{true, false}})
+}
+
+type SUnits struct {
+	Mask  uint    `default:"0xff"`
+	Limit int     `default:"1_000"`
+	Cache Size    `default:"512MiB"`
+	Disk  uint64  `unit:"bytes" default:"2GB"`
+	Ratio float64 `unit:"percent" default:"50%"`
+}
+
+func (_ *SUnits) Run() error {
+	return nil
+}
+
+func TestArgpUnits(t *testing.T) {
+	s := SUnits{}
+	argp := NewCmd(&s, "description")
+	_, _, err := argp.parse([]string{})
+	test.Error(t, err)
+	test.T(t, s, SUnits{255, 1000, 512 << 20, 2e9, 0.5})
+
+	options := argp.Help().Options[0].Options
+	test.T(t, options[0], HelpOption{"", "cache", "512MiB", "size", ""})
+	test.T(t, options[1], HelpOption{"", "disk", "2GB", "size", ""})
+	test.T(t, options[5], HelpOption{"", "ratio", "50%", "percent", ""})
+
+	_, _, err = argp.parse([]string{"--mask", "0b101", "--limit", "-0o17", "--cache", "1.5k", "--disk", "3Ki", "--ratio", "0.25"})
+	test.Error(t, err)
+	test.T(t, s, SUnits{5, -15, 1500, 3072, 0.25})
+
+	_, _, err = argp.parse([]string{"--limit", "010"})
+	test.Error(t, err)
+	test.T(t, s.Limit, 10)
+
+	_, _, err = argp.parse([]string{"--cache", "5XB"})
+	test.T(t, err, fmt.Errorf("option --cache: invalid size '5XB'"))
+	_, _, err = argp.parse([]string{"--disk", "-5"})
+	test.T(t, err, fmt.Errorf("option --disk: invalid positive size '-5'"))
+
+	test.T(t, Size(1000).String(), "1kB")
+	test.T(t, Size(1536).String(), "1536B")
+	test.T(t, Size(3<<30).String(), "3GiB")
+}
+
+type SBytes struct {
+	Key  []byte  `default:"00ff"`
+	Salt [4]byte `encoding:"base64" default:"AQIDBA=="`
+	List []byte
+}
+
+func (_ *SBytes) Run() error {
+	return nil
+}
+
+func TestArgpBytes(t *testing.T) {
+	s := SBytes{}
+	argp := NewCmd(&s, "description")
+	_, _, err := argp.parse([]string{})
+	test.Error(t, err)
+	test.T(t, s.Key, []byte{0x00, 0xff})
+	test.T(t, s.Salt, [4]byte{1, 2, 3, 4})
+
+	options := argp.Help().Options[0].Options
+	test.T(t, options[1], HelpOption{"", "key", "00ff", "hex", ""})
+	test.T(t, options[3], HelpOption{"", "salt", "AQIDBA==", "base64", ""})
+
+	_, _, err = argp.parse([]string{"--key", "base64:c2VjcmV0", "--salt", "hex:0a0b0c0d", "--list", "[1", "2", "3]"})
+	test.Error(t, err)
+	test.T(t, s.Key, []byte("secret"))
+	test.T(t, s.Salt, [4]byte{10, 11, 12, 13})
+	test.T(t, s.List, []byte{1, 2, 3})
+
+	_, _, err = argp.parse([]string{"--salt", "AQID"})
+	test.T(t, err, fmt.Errorf("option --salt: expected 4 bytes"))
+	_, _, err = argp.parse([]string{"--key", "xyz"})
+	test.T(t, err, fmt.Errorf("option --key: invalid hex 'xyz'"))
+}
+
+type SFiles struct {
+	Input  InputFile  `index:"0"`
+	Output OutputFile `short:"o" default:"-"`
+}
+
+func (cmd *SFiles) Run() error {
+	_, err := io.Copy(&cmd.Output, &cmd.Input)
+	return err
+}
+
+func TestArgpFiles(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	output := filepath.Join(dir, "output.txt.gz")
+	test.Error(t, os.WriteFile(input, []byte("contents"), 0644))
+
+	s := SFiles{}
+	argp := NewCmd(&s, "description")
+	test.T(t, s.Output.Filename, "-")
+	test.T(t, argp.Help().Options[0].Options[1], HelpOption{"o", "output", "-", "file", ""})
+
+	_, _, err := argp.parse([]string{"-o", output, input})
+	test.Error(t, err)
+	test.Error(t, argp.run())
+
+	// read back compressed output
+	s.Input = InputFile{Filename: output}
+	b, err := io.ReadAll(&s.Input)
+	test.Error(t, err)
+	test.T(t, string(b), "contents")
+	test.Error(t, s.Input.Close())
+
+	_, _, err = argp.parse([]string{filepath.Join(dir, "missing.txt")})
+	test.Error(t, err)
+	test.That(t, argp.run() != nil)
+}
+
+type SPaths struct {
+	Config Config   `name:"config"`
+	Input  string   `path:"file,exists"`
+	Cache  string   `path:"dir,create" default:"~/cache"`
+	Files  []string `index:"*" path:"file,glob"`
+}
+
+func (_ *SPaths) Run() error {
+	return nil
+}
+
+func TestArgpPaths(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("ARGP_DIR", dir)
+	test.Error(t, os.WriteFile(filepath.Join(dir, "a.txt"), nil, 0644))
+	test.Error(t, os.WriteFile(filepath.Join(dir, "b.txt"), nil, 0644))
+	test.Error(t, os.Mkdir(filepath.Join(dir, "conf"), 0755))
+	test.Error(t, os.WriteFile(filepath.Join(dir, "conf", "c.txt"), nil, 0644))
+	test.Error(t, os.WriteFile(filepath.Join(dir, "conf", "config.toml"), []byte("input = \"c.txt\"\n"), 0644))
+
+	s := SPaths{}
+	argp := NewCmd(&s, "description")
+	s.Config.Argp = argp
+	_, _, err := argp.parse([]string{"--input", "$ARGP_DIR/a.txt", "--cache", "~/out/cache", filepath.Join(dir, "*.txt"), "~/missing.txt"})
+	test.Error(t, err)
+	test.T(t, s.Input, filepath.Join(dir, "a.txt"))
+	test.T(t, s.Cache, filepath.Join(dir, "out", "cache"))
+	test.T(t, s.Files, []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), filepath.Join(dir, "missing.txt")})
+	info, err := os.Stat(s.Cache)
+	test.Error(t, err)
+	test.That(t, info.IsDir())
+
+	// defaults are expanded but not created
+	_, _, err = argp.parse([]string{"--config", filepath.Join(dir, "conf", "config.toml")})
+	test.Error(t, err)
+	test.T(t, s.Input, filepath.Join(dir, "conf", "c.txt"))
+	test.T(t, s.Cache, filepath.Join(dir, "cache"))
+	_, err = os.Stat(s.Cache)
+	test.That(t, os.IsNotExist(err))
+
+	_, _, err = argp.parse([]string{"--input", filepath.Join(dir, "missing.txt")})
+	test.That(t, err != nil)
+	_, _, err = argp.parse([]string{"--input", dir})
+	test.T(t, err, fmt.Errorf("option --input: %v: is a directory", dir))
+	_, _, err = argp.parse([]string{filepath.Join(dir, "conf")})
+	test.T(t, err, fmt.Errorf("argument files: %v: is a directory", filepath.Join(dir, "conf")))
+
+	// paths given by setting options
+	argp.AddOpt(Set{argp}, "", "set", "description")
+	_, _, err = argp.parse([]string{"--set", "input=~/b.txt"})
+	test.Error(t, err)
+	test.T(t, s.Input, filepath.Join(dir, "b.txt"))
+	_, _, err = argp.parse([]string{"--set", "input=~/missing.txt"})
+	test.That(t, err != nil)
+}
+
+func TestArgpNegativeNumbers(t *testing.T) {
+	var f float64
+	var i int
+	var a bool
+	argp := New("description")
+	argp.AddOpt(&i, "i", "int", "description")
+	argp.AddOpt(&a, "a", "", "description")
+	argp.AddArg(&f, "", "description")
+
+	_, rest, err := argp.parse([]string{"-1e3", "--int", "-5", "-a", "-3"})
+	test.Error(t, err)
+	test.T(t, f, -1e3)
+	test.T(t, i, -5)
+	test.T(t, rest, []string{"-3"})
+
+	argp.NegativeNumbers = false
+	_, _, err = argp.parse([]string{"-3"})
+	test.T(t, err, fmt.Errorf("unknown option -3"))
+
+	// digit short option
+	var s string
+	var one bool
+	argp = New("description")
+	argp.AddOpt(&one, "1", "one", "description")
+	argp.AddArg(&f, "", "description")
+	argp.AddArg(&s, "", "description")
+	_, _, err = argp.parse([]string{"-1.5", "-1"})
+	test.Error(t, err)
+	test.T(t, f, -1.5)
+	test.T(t, one, true)
+	test.T(t, s, "")
+	_, _, err = argp.parse([]string{"0", "-2"})
+	test.T(t, err, fmt.Errorf("unknown option -2"))
+
+	var c int
+	argp = New("description")
+	argp.AddOpt(Count{&c}, "c", "count", "description")
+	_, _, err = argp.parse([]string{"-c", "-2"})
+	test.Error(t, err)
+	test.T(t, c, -2)
+
+	argp.NegativeNumbers = false
+	_, _, err = argp.parse([]string{"-c", "-2"})
+	test.T(t, err, fmt.Errorf("unknown option -2"))
+
+	// counting option with digit short option
+	c, one = 0, false
+	argp = New("description")
+	argp.AddOpt(Count{&c}, "c", "count", "description")
+	argp.AddOpt(&one, "1", "one", "description")
+	_, _, err = argp.parse([]string{"-c", "-1"})
+	test.Error(t, err)
+	test.T(t, c, 1)
+	test.T(t, one, true)
+	_, _, err = argp.parse([]string{"-c=-2"})
+	test.Error(t, err)
+	test.T(t, c, -2)
+}
+
+type SStrict struct {
+	Verbose bool     `short:"v"`
+	Cmd     string   `index:"0" stop:""`
+	Args    []string `index:"*"`
+}
+
+func (_ *SStrict) Run() error {
+	return nil
+}
+
+type SStrictPair struct {
+	Verbose bool     `short:"v"`
+	Pair    []int    `index:"0"`
+	Cmd     string   `index:"1" stop:""`
+	Args    []string `index:"*"`
+}
+
+func (_ *SStrictPair) Run() error {
+	return nil
+}
+
+func TestArgpStrict(t *testing.T) {
+	s := SStrict{}
+	argp := NewCmd(&s, "description")
+	_, rest, err := argp.parse([]string{"-v", "ls", "-la", "--", "dir"})
+	test.Error(t, err)
+	test.T(t, s, SStrict{true, "ls", []string{"-la", "--", "dir"}})
+	test.T(t, rest, []string{})
+
+	var v bool
+	sub := SSub1{}
+	argp = New("description")
+	argp.AddOpt(&v, "v", "", "description")
+	argp.AddCmd(&sub, "exec", "description")
+	argp.Strict = true
+	_, rest, err = argp.parse([]string{"-v", "cmd", "-v"})
+	test.Error(t, err)
+	test.T(t, v, true)
+	test.T(t, rest, []string{"cmd", "-v"})
+
+	_, rest, err = argp.parse([]string{"exec", "-b", "1", "cmd", "-b", "2"})
+	test.Error(t, err)
+	test.T(t, sub.B, 1)
+	test.T(t, rest, []string{"cmd", "-b", "2"})
+
+	// stop after an argument spanning multiple values
+	sPair := SStrictPair{}
+	argp = NewCmd(&sPair, "description")
+	_, rest, err = argp.parse([]string{"[1", "2]", "-v", "cmd", "-x"})
+	test.Error(t, err)
+	test.T(t, sPair, SStrictPair{true, []int{1, 2}, "cmd", []string{"-x"}})
+	test.T(t, rest, []string{})
+
+	// stop after a variadic argument with a fixed number of values
+	var pair []string
+	var cmd string
+	v = false
+	argp = New("description")
+	argp.AddOpt(&v, "v", "", "description")
+	variadic := argp.AddArg(&pair, "pair", "description")
+	variadic.Min, variadic.Max = 2, 2
+	argp.AddArg(&cmd, "cmd", "description").Stop = true
+	_, rest, err = argp.parse([]string{"a", "-v", "b", "cmd", "-v"})
+	test.Error(t, err)
+	test.T(t, v, true)
+	test.T(t, pair, []string{"a", "b"})
+	test.T(t, cmd, "cmd")
+	test.T(t, rest, []string{"-v"})
+
+	// stop after a variadic argument with a variable number of values
+	argp = New("description")
+	variadic = argp.AddArg(&pair, "pair", "description")
+	variadic.Min, variadic.Max = 1, -1
+	argp.AddArg(&cmd, "cmd", "description").Stop = true
+	_, _, err = argp.parse([]string{"x", "y"})
+	test.T(t, err, fmt.Errorf("argument 1: stop argument can not follow variadic argument 0 with a variable number of values"))
+}
+
+func TestArgpPassUnknown(t *testing.T) {
+	var a bool
+	var b int
+	var input string
+	argp := New("description")
+	argp.AddOpt(&a, "a", "", "description")
+	argp.AddOpt(&b, "b", "", "description")
+	argp.AddArg(&input, "input", "description")
+	argp.PassUnknown = true
+
+	_, rest, err := argp.parse([]string{"--foo=1", "-a", "-x", "file", "--bar", "-b", "2", "-axy", "more"})
+	test.Error(t, err)
+	test.T(t, a, true)
+	test.T(t, b, 2)
+	test.T(t, input, "file")
+	test.T(t, rest, []string{"--foo=1", "-x", "--bar", "-xy", "more"})
+}
+
 func TestArgpUTF8(t *testing.T) {
 	var v bool
 	argp := New("description")
@@ -373,6 +1065,75 @@ func TestArgpAppend(t *testing.T) {
 	test.T(t, s, []string{"foo", "bar"})
 }
 
+type SOptional struct {
+	Color string `short:"c" optional:"auto" default:"never"`
+	Level int    `short:"l" optional:"1"`
+}
+
+func (_ *SOptional) Run() error {
+	return nil
+}
+
+type SOptionalBad struct {
+	Level int `optional:"abc"`
+}
+
+func (_ *SOptionalBad) Run() error {
+	return nil
+}
+
+func TestArgpOptional(t *testing.T) {
+	tests := []struct {
+		args []string
+		s    SOptional
+		rest string
+	}{
+		{[]string{}, SOptional{"never", 0}, ""},
+		{[]string{"--color"}, SOptional{"auto", 0}, ""},
+		{[]string{"--color", "always"}, SOptional{"auto", 0}, "always"},
+		{[]string{"--color=always"}, SOptional{"always", 0}, ""},
+		{[]string{"--color="}, SOptional{"", 0}, ""},
+		{[]string{"-c", "always"}, SOptional{"auto", 0}, "always"},
+		{[]string{"-calways"}, SOptional{"always", 0}, ""},
+		{[]string{"-c=always"}, SOptional{"always", 0}, ""},
+		{[]string{"-l", "5"}, SOptional{"never", 1}, "5"},
+		{[]string{"-l5"}, SOptional{"never", 5}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v", tt.args), func(t *testing.T) {
+			s := SOptional{}
+			argp := NewCmd(&s, "description")
+			_, rest, err := argp.parse(tt.args)
+			test.Error(t, err)
+			test.T(t, s, tt.s)
+			test.T(t, strings.Join(rest, " "), tt.rest)
+		})
+	}
+
+	var color string
+	argp := New("description")
+	argp.AddOpt(Optional{&color, "auto"}, "", "color", "description")
+	_, rest, err := argp.parse([]string{"--color", "file"})
+	test.Error(t, err)
+	test.T(t, color, "auto")
+	test.T(t, rest, []string{"file"})
+
+	var level int
+	func() {
+		defer func() {
+			test.T(t, recover(), "--level: bad implicit value: invalid integer 'abc'")
+		}()
+		argp.AddOpt(Optional{&level, "abc"}, "", "level", "description")
+	}()
+	func() {
+		defer func() {
+			test.T(t, recover(), "*argp.SOptionalBad.Level: bad implicit value: invalid integer 'abc'")
+		}()
+		NewCmd(&SOptionalBad{}, "description")
+	}()
+}
+
 type SSub1 struct {
 	B int `short:"b"`
 }
@@ -414,6 +1175,211 @@ func TestArgpSubCommand(t *testing.T) {
 	test.T(t, sub2.C, 3)
 }
 
+func TestHelp(t *testing.T) {
+	var input string
+	var size int = 512
+	argp := New("description")
+	argp.name = "test"
+	argp.AddOpt(&size, "s", "size", "Image size")
+	argp.AddOpt(&input, "", "host", "Server host").Group = "Network"
+	argp.AddArg(&input, "input", "Input file")
+	sub := argp.AddCmd(&SSub1{}, "one", "Sub command")
+
+	help := argp.Help()
+	test.T(t, help.Usage, []string{"test [options] [command] ...", "test [options] input"})
+	test.T(t, len(help.Options), 2)
+	test.T(t, help.Options[0].Name, "Options")
+	test.T(t, help.Options[0].Options[2], HelpOption{"s", "size", "512", "int", "Image size"})
+	test.T(t, help.Options[1].Name, "Network")
+	test.T(t, help.Commands, []HelpItem{{"one", "Sub command"}})
+	test.T(t, help.Arguments, []HelpItem{{"input", "Input file"}})
+
+	b := &bytes.Buffer{}
+	test.Error(t, argp.WriteHelp(b))
+	test.T(t, b.String(), `Usage: test [options] [command] ...
+Usage: test [options] input
+
+Options:
+  -h, --help         Help
+      --help-all     Help for all commands
+  -s, --size=512 int Image size
+
+Network:
+      --host string  Server host
+
+Commands:
+  one       Sub command
+
+Arguments:
+  input     Input file
+`)
+
+	b.Reset()
+	argp.Formatter = TemplateFormatter{template.Must(template.New("help").Parse(`{{.Name}}: {{.Description}}
+{{range .Options}}{{range .Options}}--{{.Name}} {{end}}{{end}}`))}
+	test.Error(t, argp.WriteHelp(b))
+	test.T(t, b.String(), "test: description\n--help --help-all --size --host ")
+
+	// sub commands use the formatter of the parent
+	b.Reset()
+	test.Error(t, sub.WriteHelp(b))
+	test.T(t, b.String(), "test one: Sub command\n--b --help ")
+}
+
+type SInfo struct {
+	Verbose bool
+}
+
+func (_ *SInfo) Run() error {
+	return nil
+}
+
+func (_ *SInfo) Info() CmdInfo {
+	return CmdInfo{
+		LongDescription: "Long description.",
+		Examples:        []string{"test --verbose"},
+		Epilog:          "Report bugs to bugs@example.com",
+	}
+}
+
+func TestHelpInfo(t *testing.T) {
+	argp := New("description")
+	argp.name = "test"
+	sub := argp.AddCmd(&SInfo{}, "info", "Short description")
+	test.T(t, sub.LongDescription, "Long description.")
+	test.T(t, sub.Examples, []string{"test --verbose"})
+	test.T(t, sub.Epilog, "Report bugs to bugs@example.com")
+	test.T(t, argp.Help().Commands, []HelpItem{{"info", "Short description"}})
+
+	b := &bytes.Buffer{}
+	test.Error(t, sub.WriteHelp(b))
+	test.T(t, b.String(), "Usage: test info [options]\n\nLong description.\n\nOptions:\n  -h, --help    Help\n      --verbose \n\nExamples:\n  test --verbose\n\nReport bugs to bugs@example.com\n")
+}
+
+type SPlaceholder struct {
+	Output string         `short:"o" placeholder:"FILE"`
+	Size   int            "default:\"512\" desc:\"Image size in `PIXELS`\""
+	Labels map[string]int `desc:"Labels"`
+	Point  []STypesStruct `desc:"Points"`
+}
+
+func (_ *SPlaceholder) Run() error {
+	return nil
+}
+
+func TestHelpPlaceholder(t *testing.T) {
+	s := SPlaceholder{}
+	argp := NewCmd(&s, "description")
+	var input string
+	argp.AddOpt(&input, "i", "input", "Input file").Placeholder = "FILE"
+
+	options := argp.Help().Options[0].Options
+	test.T(t, options[1], HelpOption{"i", "input", "", "FILE", "Input file"})
+	test.T(t, options[2], HelpOption{"", "labels", "", "{string:int ...}", "Labels"})
+	test.T(t, options[3], HelpOption{"o", "output", "", "FILE", ""})
+	test.T(t, options[4], HelpOption{"", "point", "", "[]{bool {float}}", "Points"})
+	test.T(t, options[5], HelpOption{"", "size", "512", "PIXELS", "Image size in PIXELS"})
+}
+
+func TestWrapString(t *testing.T) {
+	tests := []struct {
+		s    string
+		cols int
+		line string
+		rest string
+	}{
+		{"foo bar", 10, "foo bar", ""},
+		{"foo bar baz", 9, "foo bar", "baz"},
+		{"foobarbazquxquux", 10, "foobarbazq", "uxquux"},
+		{"föö bär bäz", 9, "föö bär", "bäz"},
+		{"日本語の説明 です", 10, "日本語の説", "明 です"},
+		{"日本語の 説明です", 10, "日本語の", "説明です"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			line, rest := wrapString(tt.s, tt.cols)
+			test.T(t, line, tt.line)
+			test.T(t, rest, tt.rest)
+		})
+	}
+	test.T(t, stringWidth("e\u0301日本"), 5)
+}
+
+func TestGenerateDocs(t *testing.T) {
+	var size int
+	argp := New("description")
+	argp.name = "test"
+	argp.AddOpt(&size, "s", "size", "Image size")
+	argp.AddCmd(&SSub1{}, "one", "Sub command")
+
+	dir := t.TempDir()
+	_, _, err := argp.parse([]string{"--generate-docs", dir})
+	test.Error(t, err)
+	test.T(t, argp.docs, dir)
+	test.Error(t, argp.GenerateDocs(dir))
+
+	man, err := os.ReadFile(filepath.Join(dir, "test-one.1"))
+	test.Error(t, err)
+	test.That(t, strings.Contains(string(man), ".SH NAME\ntest\\-one \\- Sub command\n"))
+	test.That(t, strings.Contains(string(man), ".SH SEE ALSO\n\\fBtest\\fR(1)\n"))
+	test.That(t, !strings.Contains(string(man), "generate\\-docs"))
+
+	md, err := os.ReadFile(filepath.Join(dir, "test.md"))
+	test.Error(t, err)
+	test.That(t, strings.Contains(string(md), "- `-s, --size int`: Image size\n"))
+	test.That(t, strings.Contains(string(md), "## Commands\n\n- [one](test-one.md): Sub command\n"))
+
+	test.T(t, roffEscape(".start\nmiddle\n'quote\n.end"), "\\&.start\nmiddle\n\\&'quote\n\\&.end")
+}
+
+func TestArgpHelpCommand(t *testing.T) {
+	argp := New("description")
+	argp.name = "test"
+	one := argp.AddCmd(&SSub1{}, "one", "First")
+	two := one.AddCmd(&SSub2{}, "two", "Second")
+	hidden := argp.AddCmd(&SSub2{}, "hidden", "Hidden")
+	hidden.Hidden = true
+
+	cmd, _, err := argp.parse([]string{"help", "one", "two"})
+	test.Error(t, err)
+	test.T(t, cmd, two)
+	test.T(t, cmd.help, true)
+
+	cmd, _, err = argp.parse([]string{"help", "hidden"})
+	test.Error(t, err)
+	test.T(t, cmd, hidden)
+
+	_, _, err = argp.parse([]string{"help", "three"})
+	test.T(t, err, fmt.Errorf("unknown command three"))
+
+	cmd, _, err = argp.parse([]string{"--help-all"})
+	test.Error(t, err)
+	test.T(t, cmd.helpAll, true)
+
+	test.T(t, argp.Help().Commands, []HelpItem{{"one", "First"}})
+
+	b := &bytes.Buffer{}
+	test.Error(t, argp.WriteHelpAll(b))
+	test.That(t, strings.Contains(b.String(), "\nUsage: test one [options] [command] ...\n"))
+	test.That(t, strings.Contains(b.String(), "\nUsage: test one two [options]\n"))
+	test.That(t, !strings.Contains(b.String(), "Usage: test hidden"))
+}
+
+func TestArgpVersion(t *testing.T) {
+	argp := New("description")
+	argp.name = "test"
+	argp.SetVersion("v1.2.3")
+
+	_, _, err := argp.parse([]string{"-V"})
+	test.Error(t, err)
+	test.T(t, argp.showVersion, true)
+
+	b := &bytes.Buffer{}
+	argp.VersionTemplate = "{{.Name}} version {{.Version}}\n"
+	test.Error(t, argp.WriteVersion(b))
+	test.T(t, b.String(), "test version v1.2.3\n")
+}
+
 func TestSplitArguments(t *testing.T) {
 	tests := []struct {
 		str  string
@@ -529,3 +1495,50 @@ func ExampleCustom() {
 	fmt.Println(custom.Num, "/", custom.Div)
 	// Output: 1 / 2
 }
+
+type SSecret struct {
+	Password string `secret:"" default:"hunter2"`
+	Token    Secret
+}
+
+func (_ *SSecret) Run() error {
+	return nil
+}
+
+func TestArgpSecret(t *testing.T) {
+	t.Setenv("ARGP_TOKEN", "token")
+
+	s := SSecret{}
+	argp := NewCmd(&s, "description")
+	buf := &bytes.Buffer{}
+	argp.Error = log.New(buf, "", 0)
+	test.T(t, argp.Help().Options[0].Options[1], HelpOption{"", "password", "", "secret", ""})
+	test.T(t, argp.Help().Options[0].Options[2], HelpOption{"", "token", "", "secret", ""})
+
+	_, _, err := argp.parse([]string{"--token", "env:ARGP_TOKEN"})
+	test.Error(t, err)
+	test.T(t, s.Token, Secret("token"))
+	test.T(t, s.Password, "hunter2")
+	test.T(t, buf.String(), "")
+
+	_, _, err = argp.parse([]string{"--password", "pw"})
+	test.Error(t, err)
+	test.T(t, s.Password, "pw")
+	test.T(t, buf.String(), "WARNING: option --password is a secret that may be visible to other users, use file:, env:, or - instead\n")
+
+	// secrets given by setting options
+	buf.Reset()
+	argp.AddOpt(Set{argp}, "", "set", "description")
+	_, _, err = argp.parse([]string{"--set", "token=env:ARGP_TOKEN"})
+	test.Error(t, err)
+	test.T(t, s.Token, Secret("token"))
+	test.T(t, buf.String(), "")
+	_, _, err = argp.parse([]string{"--set", "password=pw2"})
+	test.Error(t, err)
+	test.T(t, s.Password, "pw2")
+	test.T(t, buf.String(), "WARNING: option --set password is a secret that may be visible to other users, use file:, env:, or - instead\n")
+
+	test.T(t, fmt.Sprint(s.Token), "********")
+	test.T(t, fmt.Sprintf("%#v", s.Token), `"********"`)
+	test.T(t, fmt.Sprint(Secret("")), "")
+}
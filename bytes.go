@@ -0,0 +1,65 @@
+package argp
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var byteType = reflect.TypeOf(byte(0))
+
+// isBytesType returns true for byte slices and arrays.
+func isBytesType(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem() == byteType
+}
+
+// decodeBytes decodes a hex or base64 string, where a hex: or base64: prefix overrides the given encoding.
+func decodeBytes(s, encoding string) ([]byte, error) {
+	if strings.HasPrefix(s, "hex:") {
+		encoding, s = "hex", s[4:]
+	} else if strings.HasPrefix(s, "base64:") {
+		encoding, s = "base64", s[7:]
+	}
+
+	switch encoding {
+	case "hex":
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex '%v'", s)
+		}
+		return b, nil
+	case "base64":
+		for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+			if b, err := enc.DecodeString(s); err == nil {
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid base64 '%v'", s)
+	}
+	return nil, fmt.Errorf("unknown encoding %v", encoding)
+}
+
+// encodeBytes encodes a byte slice or array as hex or base64.
+func encodeBytes(v reflect.Value, encoding string) string {
+	b := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(b), v)
+	if encoding == "base64" {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	return hex.EncodeToString(b)
+}
+
+// setBytes sets a byte slice or array, where an array must have the same length.
+func setBytes(v reflect.Value, b []byte) error {
+	if v.Kind() == reflect.Array {
+		if len(b) != v.Len() {
+			return fmt.Errorf("expected %v bytes", v.Len())
+		}
+		reflect.Copy(v, reflect.ValueOf(b))
+	} else {
+		v.Set(reflect.ValueOf(b).Convert(v.Type()))
+	}
+	return nil
+}
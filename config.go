@@ -88,6 +88,11 @@ func (config *Config) unmarshal(prefix string, values map[string]interface{}) er
 			return fmt.Errorf("%s: %v", name, err)
 		} else if n != len(vals) {
 			return fmt.Errorf("%s: invalid value", name)
+		} else if v.Path != "" {
+			// relative to the configuration file
+			if err := resolvePaths(v.Value, v.Path, filepath.Dir(config.Filename), true); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
 		}
 	}
 	return nil
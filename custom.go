@@ -3,6 +3,7 @@ package argp
 import (
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 type Custom interface {
@@ -30,13 +31,13 @@ func (count Count) Scan(name string, s []string) (int, error) {
 	}
 	v := reflect.ValueOf(count.I).Elem()
 	t := v.Type().Kind()
-	isInt := t == reflect.Int || t == reflect.Int8 || t == reflect.Int16 || t == reflect.Int32 || t != reflect.Int64
+	isInt := t == reflect.Int || t == reflect.Int8 || t == reflect.Int16 || t == reflect.Int32 || t == reflect.Int64
 	isUint := t == reflect.Uint || t == reflect.Uint8 || t == reflect.Uint16 || t == reflect.Uint32 || t == reflect.Uint64
 	if !isInt && !isUint {
 		return 0, fmt.Errorf("variable must be a pointer to an integer type")
 	}
-	if 0 < len(s) && 0 < len(s[0]) && '0' <= s[0][0] && s[0][0] <= '9' {
-		// don't parse negatives or other options
+	if 0 < len(s) && 0 < len(s[0]) && ('0' <= s[0][0] && s[0][0] <= '9' || isInt && isNegativeNumber(s[0])) {
+		// don't parse other options
 		return scanValue(v, s)
 	} else if isInt {
 		v.SetInt(v.Int() + 1)
@@ -72,3 +73,173 @@ func (appnd Append) Scan(name string, s []string) (int, error) {
 	}
 	return n, err
 }
+
+// Optional is an option with an optional value that must be attached, e.g. --color sets the implicit value and --color=always sets the value to always. The next argument is never consumed as its value. Pass it to AddOpt.
+type Optional struct {
+	I        interface{}
+	Implicit string
+}
+
+// Merge is an option that merges into a map or struct, e.g. -l a=1 -l {b:2} sets the value to {a:1 b:2}. For structs, only the given fields are set, e.g. -s port=80. Values can also be set by path, e.g. --labels.a 1.
+type Merge struct {
+	I interface{}
+}
+
+func (merge Merge) Help() (string, string) {
+	val := ""
+	v := reflect.ValueOf(merge.I).Elem()
+	if !v.IsZero() && (v.Kind() != reflect.Map || 0 < v.Len()) {
+		val = fmt.Sprint(v.Interface())
+	}
+	return val, TypeName(v.Type())
+}
+
+func (merge Merge) Scan(name string, s []string) (int, error) {
+	if t := reflect.TypeOf(merge.I); t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Map && t.Elem().Kind() != reflect.Struct {
+		return 0, fmt.Errorf("variable must be a pointer to a map or struct")
+	} else if strings.IndexAny(name, ".[") != -1 {
+		// set by path, e.g. --labels.key value
+		return scanVar(reflect.ValueOf(merge.I).Elem(), name, s)
+	} else if len(s) == 0 {
+		return 0, fmt.Errorf("missing value")
+	}
+
+	v := reflect.ValueOf(merge.I).Elem()
+	if 0 < len(s[0]) && s[0][0] == '{' {
+		val := reflect.New(v.Type()).Elem()
+		if v.Kind() == reflect.Struct {
+			val.Set(v)
+		}
+		n, err := scanValue(val, s)
+		if err != nil {
+			return 0, err
+		} else if v.Kind() == reflect.Struct {
+			v.Set(val)
+		} else {
+			if v.IsNil() {
+				v.Set(reflect.MakeMap(v.Type()))
+			}
+			iter := val.MapRange()
+			for iter.Next() {
+				v.SetMapIndex(iter.Key(), iter.Value())
+			}
+		}
+		return n, nil
+	}
+
+	is := indexByte(s[0], '=')
+	if is == -1 {
+		return 0, fmt.Errorf("expected key=value or {key:value ...}")
+	}
+	key, val := s[0][:is], s[0][is+1:]
+	vals := []string{val}
+	if 0 < len(val) && (val[0] == '[' || val[0] == '{') {
+		vals = splitArguments(val)
+	}
+	if n, err := scanVar(v, name+"["+key+"]", vals); err != nil {
+		return 0, err
+	} else if n != len(vals) {
+		return 0, fmt.Errorf("%v: invalid value", key)
+	}
+	return 1, nil
+}
+
+// Unit is an option with a unit, which is either bytes for integers, e.g. --cache 512MiB sets the value to 536870912, or percent for floats, e.g. --ratio 50% sets the value to 0.5. The help message shows the value in the same unit.
+type Unit struct {
+	I    interface{}
+	Unit string
+}
+
+func (unit Unit) Help() (string, string) {
+	val := ""
+	v := reflect.ValueOf(unit.I).Elem()
+	if !v.IsZero() {
+		switch unit.Unit {
+		case "bytes":
+			switch v.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				val = formatSize(v.Int())
+			default:
+				val = formatSize(int64(v.Uint()))
+			}
+		case "percent":
+			val = formatPercent(v.Float())
+		}
+	}
+	if unit.Unit == "bytes" {
+		return val, "size"
+	}
+	return val, unit.Unit
+}
+
+func (unit Unit) Scan(name string, s []string) (int, error) {
+	if t := reflect.TypeOf(unit.I); t.Kind() != reflect.Ptr {
+		return 0, fmt.Errorf("variable must be a pointer")
+	} else if len(s) == 0 {
+		return 0, fmt.Errorf("missing value")
+	}
+
+	v := reflect.ValueOf(unit.I).Elem()
+	t := v.Type().Kind()
+	switch unit.Unit {
+	case "bytes":
+		isInt := t == reflect.Int || t == reflect.Int8 || t == reflect.Int16 || t == reflect.Int32 || t == reflect.Int64
+		isUint := t == reflect.Uint || t == reflect.Uint8 || t == reflect.Uint16 || t == reflect.Uint32 || t == reflect.Uint64
+		if !isInt && !isUint {
+			return 0, fmt.Errorf("variable must be a pointer to an integer type")
+		}
+		i, err := parseSize(s[0])
+		if err != nil {
+			return 0, err
+		} else if isUint {
+			if i < 0 {
+				return 0, fmt.Errorf("invalid positive size '%v'", s[0])
+			}
+			v.SetUint(uint64(i))
+		} else {
+			v.SetInt(i)
+		}
+	case "percent":
+		if t != reflect.Float32 && t != reflect.Float64 {
+			return 0, fmt.Errorf("variable must be a pointer to a floating-point type")
+		}
+		f, err := parsePercent(s[0])
+		if err != nil {
+			return 0, err
+		}
+		v.SetFloat(f)
+	default:
+		return 0, fmt.Errorf("unknown unit %v", unit.Unit)
+	}
+	return 1, nil
+}
+
+// Encoding is an option for a byte slice or array that is encoded as hex or base64, e.g. --key c2VjcmV0 with base64 encoding. Values with a hex: or base64: prefix are decoded accordingly.
+type Encoding struct {
+	I        interface{}
+	Encoding string
+}
+
+func (encoding Encoding) Help() (string, string) {
+	val := ""
+	v := reflect.ValueOf(encoding.I).Elem()
+	if !v.IsZero() && 0 < v.Len() {
+		val = encodeBytes(v, encoding.Encoding)
+	}
+	return val, encoding.Encoding
+}
+
+func (encoding Encoding) Scan(name string, s []string) (int, error) {
+	if t := reflect.TypeOf(encoding.I); t.Kind() != reflect.Ptr || !isBytesType(t.Elem()) {
+		return 0, fmt.Errorf("variable must be a pointer to a byte slice or array")
+	} else if len(s) == 0 {
+		return 0, fmt.Errorf("missing value")
+	}
+	b, err := decodeBytes(s[0], encoding.Encoding)
+	if err != nil {
+		return 0, err
+	} else if err := setBytes(reflect.ValueOf(encoding.I).Elem(), b); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
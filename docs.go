@@ -0,0 +1,242 @@
+package argp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ManFormatter writes the help message as a roff man page in section 1.
+type ManFormatter struct{}
+
+func (f ManFormatter) FormatHelp(w io.Writer, help *Help) error {
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, ".TH \"%s\" \"1\"\n", roffEscape(strings.ToUpper(docName(help.Name))))
+	fmt.Fprintf(b, ".SH NAME\n%s", roffEscape(docName(help.Name)))
+	if help.Description != "" {
+		fmt.Fprintf(b, " \\- %s", roffEscape(help.Description))
+	}
+	fmt.Fprintf(b, "\n")
+
+	fmt.Fprintf(b, ".SH SYNOPSIS\n")
+	for i, usage := range help.Usage {
+		if i != 0 {
+			fmt.Fprintf(b, ".br\n")
+		}
+		fmt.Fprintf(b, "\\fB%s\\fR%s\n", roffEscape(help.Name), roffEscape(strings.TrimPrefix(usage, help.Name)))
+	}
+	if help.LongDescription != "" {
+		fmt.Fprintf(b, ".SH DESCRIPTION\n%s\n", roffEscape(help.LongDescription))
+	} else if help.Description != "" {
+		fmt.Fprintf(b, ".SH DESCRIPTION\n%s\n", roffEscape(help.Description))
+	}
+
+	for _, section := range help.Options {
+		fmt.Fprintf(b, ".SH %s\n", roffEscape(strings.ToUpper(section.Name)))
+		for _, o := range section.Options {
+			fmt.Fprintf(b, ".TP\n")
+			if o.Short != "" {
+				fmt.Fprintf(b, "\\fB\\-%s\\fR, ", roffEscape(o.Short))
+			}
+			fmt.Fprintf(b, "\\fB\\-\\-%s\\fR", roffEscape(o.Name))
+			if o.Value != "" {
+				fmt.Fprintf(b, "=%s", roffEscape(quoteValue(o.Value)))
+			}
+			if o.Type != "" {
+				fmt.Fprintf(b, " \\fI%s\\fR", roffEscape(o.Type))
+			}
+			fmt.Fprintf(b, "\n%s\n", roffEscape(o.Description))
+		}
+	}
+	if 0 < len(help.Commands) {
+		fmt.Fprintf(b, ".SH COMMANDS\n")
+		for _, cmd := range help.Commands {
+			fmt.Fprintf(b, ".TP\n\\fB%s\\fR\n%s\n", roffEscape(cmd.Name), roffEscape(cmd.Description))
+		}
+	}
+	if 0 < len(help.Arguments) {
+		fmt.Fprintf(b, ".SH ARGUMENTS\n")
+		for _, arg := range help.Arguments {
+			fmt.Fprintf(b, ".TP\n\\fI%s\\fR\n%s\n", roffEscape(arg.Name), roffEscape(arg.Description))
+		}
+	}
+	if 0 < len(help.Examples) {
+		fmt.Fprintf(b, ".SH EXAMPLES\n.nf\n")
+		for _, example := range help.Examples {
+			fmt.Fprintf(b, "%s\n", roffEscape(example))
+		}
+		fmt.Fprintf(b, ".fi\n")
+	}
+	if help.Epilog != "" {
+		fmt.Fprintf(b, ".SH NOTES\n%s\n", roffEscape(help.Epilog))
+	}
+
+	if seeAlso := docSeeAlso(help); 0 < len(seeAlso) {
+		fmt.Fprintf(b, ".SH SEE ALSO\n")
+		for i, name := range seeAlso {
+			if i != 0 {
+				fmt.Fprintf(b, ",\n")
+			}
+			fmt.Fprintf(b, "\\fB%s\\fR(1)", roffEscape(docName(name)))
+		}
+		fmt.Fprintf(b, "\n")
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// MarkdownFormatter writes the help message as a Markdown reference with links to the parent and sub commands.
+type MarkdownFormatter struct{}
+
+func (f MarkdownFormatter) FormatHelp(w io.Writer, help *Help) error {
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "# %s\n", help.Name)
+	if help.LongDescription != "" {
+		fmt.Fprintf(b, "\n%s\n", help.LongDescription)
+	} else if help.Description != "" {
+		fmt.Fprintf(b, "\n%s\n", help.Description)
+	}
+
+	fmt.Fprintf(b, "\n## Usage\n\n```\n")
+	for _, usage := range help.Usage {
+		fmt.Fprintf(b, "%s\n", usage)
+	}
+	fmt.Fprintf(b, "```\n")
+
+	for _, section := range help.Options {
+		fmt.Fprintf(b, "\n## %s\n\n", section.Name)
+		for _, o := range section.Options {
+			fmt.Fprintf(b, "- `")
+			if o.Short != "" {
+				fmt.Fprintf(b, "-%s, ", o.Short)
+			}
+			fmt.Fprintf(b, "--%s", o.Name)
+			if o.Type != "" {
+				fmt.Fprintf(b, " %s", o.Type)
+			}
+			fmt.Fprintf(b, "`")
+			if o.Value != "" {
+				fmt.Fprintf(b, " (default: `%s`)", o.Value)
+			}
+			if o.Description != "" {
+				fmt.Fprintf(b, ": %s", o.Description)
+			}
+			fmt.Fprintf(b, "\n")
+		}
+	}
+	if 0 < len(help.Commands) {
+		fmt.Fprintf(b, "\n## Commands\n\n")
+		for _, cmd := range help.Commands {
+			fmt.Fprintf(b, "- [%s](%s.md)", cmd.Name, docName(help.Name+" "+cmd.Name))
+			if cmd.Description != "" {
+				fmt.Fprintf(b, ": %s", cmd.Description)
+			}
+			fmt.Fprintf(b, "\n")
+		}
+	}
+	if 0 < len(help.Arguments) {
+		fmt.Fprintf(b, "\n## Arguments\n\n")
+		for _, arg := range help.Arguments {
+			fmt.Fprintf(b, "- `%s`", arg.Name)
+			if arg.Description != "" {
+				fmt.Fprintf(b, ": %s", arg.Description)
+			}
+			fmt.Fprintf(b, "\n")
+		}
+	}
+	if 0 < len(help.Examples) {
+		fmt.Fprintf(b, "\n## Examples\n\n```\n")
+		for _, example := range help.Examples {
+			fmt.Fprintf(b, "%s\n", example)
+		}
+		fmt.Fprintf(b, "```\n")
+	}
+	if help.Epilog != "" {
+		fmt.Fprintf(b, "\n%s\n", help.Epilog)
+	}
+
+	if seeAlso := docSeeAlso(help); 0 < len(seeAlso) {
+		fmt.Fprintf(b, "\n## See also\n\n")
+		for _, name := range seeAlso {
+			fmt.Fprintf(b, "- [%s](%s.md)\n", name, docName(name))
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// GenerateDocs writes man pages and Markdown references for the command and all its sub commands to dir.
+func (argp *Argp) GenerateDocs(dir string) error {
+	if err := argp.GenerateMan(dir); err != nil {
+		return err
+	}
+	return argp.GenerateMarkdown(dir)
+}
+
+// GenerateMan writes a man page for the command and all its sub commands to dir, such as `dir/tool-sub.1`.
+func (argp *Argp) GenerateMan(dir string) error {
+	return argp.generateDocs(dir, ".1", ManFormatter{})
+}
+
+// GenerateMarkdown writes a Markdown reference for the command and all its sub commands to dir, such as `dir/tool-sub.md`.
+func (argp *Argp) GenerateMarkdown(dir string) error {
+	return argp.generateDocs(dir, ".md", MarkdownFormatter{})
+}
+
+func (argp *Argp) generateDocs(dir, ext string, formatter HelpFormatter) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	help := argp.Help()
+	f, err := os.Create(filepath.Join(dir, docName(help.Name)+ext))
+	if err != nil {
+		return err
+	}
+	if err := formatter.FormatHelp(f, help); err != nil {
+		f.Close()
+		return err
+	} else if err := f.Close(); err != nil {
+		return err
+	}
+
+	for _, cmd := range argp.visibleCmds() {
+		if err := argp.cmds[cmd].generateDocs(dir, ext, formatter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// docName returns the document name of a command, such as tool-sub for the sub command sub of tool.
+func docName(name string) string {
+	return strings.ReplaceAll(name, " ", "-")
+}
+
+// docSeeAlso returns the names of the parent and sub commands.
+func docSeeAlso(help *Help) []string {
+	names := []string{}
+	if i := strings.LastIndexByte(help.Name, ' '); i != -1 {
+		names = append(names, help.Name[:i])
+	}
+	for _, cmd := range help.Commands {
+		names = append(names, help.Name+" "+cmd.Name)
+	}
+	return names
+}
+
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\e")
+	s = strings.ReplaceAll(s, "-", "\\-")
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if 0 < len(line) && (line[0] == '.' || line[0] == '\'') {
+			// not a control line
+			lines[i] = "\\&" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
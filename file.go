@@ -0,0 +1,154 @@
+package argp
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// InputFile is an option or argument for a file to read from, where - is standard input. The file is opened on the first read and is decompressed when its name ends in .gz. The files of a command are closed after its Run method returns.
+type InputFile struct {
+	Filename string
+
+	r       io.Reader
+	closers []io.Closer
+}
+
+func (f *InputFile) Help() (string, string) {
+	return f.Filename, "file"
+}
+
+func (f *InputFile) Scan(name string, s []string) (int, error) {
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+	return scanValue(reflect.ValueOf(&f.Filename).Elem(), s)
+}
+
+// Open opens the file if it is not yet open.
+func (f *InputFile) Open() error {
+	if f.r != nil {
+		return nil
+	} else if f.Filename == "" {
+		return fmt.Errorf("missing input file")
+	}
+
+	var r io.Reader = os.Stdin
+	if f.Filename != "-" {
+		file, err := os.Open(f.Filename)
+		if err != nil {
+			return err
+		}
+		r = file
+		f.closers = append(f.closers, file)
+	}
+	if strings.HasSuffix(f.Filename, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("%v: %v", f.Filename, err)
+		}
+		r = gz
+		f.closers = append(f.closers, gz)
+	}
+	f.r = r
+	return nil
+}
+
+func (f *InputFile) Read(b []byte) (int, error) {
+	if err := f.Open(); err != nil {
+		return 0, err
+	}
+	return f.r.Read(b)
+}
+
+// Close closes the file if it is open, but not standard input.
+func (f *InputFile) Close() error {
+	err := closeAll(f.closers)
+	f.r = nil
+	f.closers = nil
+	return err
+}
+
+// OutputFile is an option or argument for a file to write to, where - is standard output. The file is opened on the first write and is compressed when its name ends in .gz. The files of a command are closed after its Run method returns.
+type OutputFile struct {
+	Filename string
+	Flag     int         // flags to open the file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC if zero
+	Perm     os.FileMode // permissions of a created file, 0644 if zero
+
+	w       io.Writer
+	closers []io.Closer
+}
+
+func (f *OutputFile) Help() (string, string) {
+	return f.Filename, "file"
+}
+
+func (f *OutputFile) Scan(name string, s []string) (int, error) {
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+	return scanValue(reflect.ValueOf(&f.Filename).Elem(), s)
+}
+
+// Open opens or creates the file if it is not yet open.
+func (f *OutputFile) Open() error {
+	if f.w != nil {
+		return nil
+	} else if f.Filename == "" {
+		return fmt.Errorf("missing output file")
+	}
+
+	var w io.Writer = os.Stdout
+	if f.Filename != "-" {
+		flag, perm := f.Flag, f.Perm
+		if flag == 0 {
+			flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		}
+		if perm == 0 {
+			perm = 0644
+		}
+		file, err := os.OpenFile(f.Filename, flag, perm)
+		if err != nil {
+			return err
+		}
+		w = file
+		f.closers = append(f.closers, file)
+	}
+	if strings.HasSuffix(f.Filename, ".gz") {
+		gz := gzip.NewWriter(w)
+		w = gz
+		f.closers = append(f.closers, gz)
+	}
+	f.w = w
+	return nil
+}
+
+func (f *OutputFile) Write(b []byte) (int, error) {
+	if err := f.Open(); err != nil {
+		return 0, err
+	}
+	return f.w.Write(b)
+}
+
+// Close flushes and closes the file if it is open, but not standard output.
+func (f *OutputFile) Close() error {
+	err := closeAll(f.closers)
+	f.w = nil
+	f.closers = nil
+	return err
+}
+
+// closeAll closes in reverse order and returns the first error.
+func closeAll(closers []io.Closer) error {
+	var err error
+	for i := len(closers) - 1; 0 <= i; i-- {
+		if errClose := closers[i].Close(); err == nil {
+			err = errClose
+		}
+	}
+	return err
+}
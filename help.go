@@ -0,0 +1,586 @@
+package argp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Help is the help message of a command, which is passed to a HelpFormatter.
+type Help struct {
+	Name            string // command name including the names of parent commands
+	Description     string
+	LongDescription string
+	Usage           []string
+	Options         []HelpSection
+	Commands        []HelpItem
+	Arguments       []HelpItem
+	Examples        []string
+	Epilog          string
+}
+
+// HelpSection is a (grouped) list of options.
+type HelpSection struct {
+	Name    string
+	Options []HelpOption
+}
+
+// HelpOption is an option in the help message.
+type HelpOption struct {
+	Short       string
+	Name        string
+	Value       string // default or current value, empty if not set
+	Type        string
+	Description string
+}
+
+// HelpItem is a command or argument in the help message.
+type HelpItem struct {
+	Name        string
+	Description string
+}
+
+// HelpFormatter writes a help message.
+type HelpFormatter interface {
+	FormatHelp(io.Writer, *Help) error
+}
+
+// TemplateFormatter writes the help message using a text template that is executed with *Help.
+type TemplateFormatter struct {
+	*template.Template
+}
+
+func (f TemplateFormatter) FormatHelp(w io.Writer, help *Help) error {
+	return f.Execute(w, help)
+}
+
+// TextFormatter is the default help formatter that aligns options, commands, and arguments in columns and wraps descriptions to the terminal width. The width is taken from the COLUMNS environment variable, or from the terminal when writing to one.
+type TextFormatter struct {
+	Color bool // color option names, types, and values when writing to a terminal and NO_COLOR is not set
+}
+
+const (
+	colorReset = "\x1b[0m"
+	colorName  = "\x1b[1m"
+	colorValue = "\x1b[32m"
+	colorType  = "\x1b[33m"
+)
+
+func (f TextFormatter) FormatHelp(w io.Writer, help *Help) error {
+	cols, tty := outputSize(w)
+	color := f.Color && tty && os.Getenv("NO_COLOR") == ""
+
+	// limit option and item columns to a third of the terminal width
+	optionCols, itemCols := 30, 28
+	if 90 < cols {
+		optionCols, itemCols = cols/3, cols/3-2
+	}
+
+	b := &bytes.Buffer{}
+	for _, usage := range help.Usage {
+		fmt.Fprintf(b, "Usage: %s\n", usage)
+	}
+	if help.LongDescription != "" {
+		fmt.Fprintf(b, "\n")
+		writeParagraphs(b, help.LongDescription, cols)
+	}
+
+	if 0 < len(help.Options) {
+		nMax := 0
+		for _, section := range help.Options {
+			for _, o := range section.Options {
+				n := 0
+				if o.Short != "" {
+					n += 4
+					if o.Name != "" {
+						n += 4 + stringWidth(o.Name)
+					}
+				} else if o.Name != "" {
+					n += 8 + stringWidth(o.Name)
+				}
+				if o.Value != "" {
+					n += 1 + stringWidth(quoteValue(o.Value))
+				}
+				if o.Type != "" {
+					n += 1 + stringWidth(o.Type)
+				}
+				n++ // whitespace before description
+				if nMax < n {
+					nMax = n
+				}
+			}
+		}
+		if optionCols < nMax {
+			nMax = optionCols
+		} else if nMax < 10 {
+			nMax = 10
+		}
+
+		for _, section := range help.Options {
+			fmt.Fprintf(b, "\n%s:\n", section.Name)
+			for _, o := range section.Options {
+				writeOptionHelp(b, o, nMax, cols, color)
+			}
+		}
+	}
+
+	if 0 < len(help.Commands) {
+		fmt.Fprintf(b, "\nCommands:\n")
+		writeItemHelps(b, help.Commands, itemCols, color)
+	}
+
+	if 0 < len(help.Arguments) {
+		fmt.Fprintf(b, "\nArguments:\n")
+		writeItemHelps(b, help.Arguments, itemCols, color)
+	}
+
+	if 0 < len(help.Examples) {
+		fmt.Fprintf(b, "\nExamples:\n")
+		for _, example := range help.Examples {
+			fmt.Fprintf(b, "  %s\n", example)
+		}
+	}
+	if help.Epilog != "" {
+		fmt.Fprintf(b, "\n")
+		writeParagraphs(b, help.Epilog, cols)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func writeOptionHelp(w io.Writer, o HelpOption, nMax, cols int, color bool) {
+	n := 0
+	if o.Short != "" {
+		fmt.Fprintf(w, "  %s, %s", paint("-"+o.Short, colorName, color), paint("--"+o.Name, colorName, color))
+		n += 8 + stringWidth(o.Name)
+	} else if o.Name != "" {
+		fmt.Fprintf(w, "      %s", paint("--"+o.Name, colorName, color))
+		n += 8 + stringWidth(o.Name)
+	}
+	if o.Value != "" {
+		val := quoteValue(o.Value)
+		fmt.Fprintf(w, "=%s", paint(val, colorValue, color))
+		n += 1 + stringWidth(val)
+	}
+	if o.Type != "" {
+		fmt.Fprintf(w, " %s", paint(o.Type, colorType, color))
+		n += 1 + stringWidth(o.Type)
+	}
+	if nMax <= n {
+		fmt.Fprintf(w, "\n")
+		n = 0
+	}
+	fmt.Fprintf(w, "%s", strings.Repeat(" ", nMax-n))
+	desc := o.Description
+	if cols < 60 {
+		fmt.Fprintf(w, "%s\n", desc)
+	} else if 0 < len(desc) {
+		n = nMax
+		for {
+			var s string
+			s, desc = wrapString(desc, cols-n)
+			fmt.Fprintf(w, "%s\n", s)
+			if len(desc) == 0 {
+				break
+			}
+			fmt.Fprint(w, strings.Repeat(" ", n))
+		}
+	} else {
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+func writeItemHelps(w io.Writer, items []HelpItem, itemCols int, color bool) {
+	nMax := 0
+	for _, item := range items {
+		if nMax < 2+stringWidth(item.Name) {
+			nMax = 2 + stringWidth(item.Name)
+		}
+	}
+	if itemCols < nMax {
+		nMax = itemCols
+	} else if nMax < 10 {
+		nMax = 10
+	}
+	for _, item := range items {
+		n := 2 + stringWidth(item.Name)
+		fmt.Fprintf(w, "  %s", paint(item.Name, colorName, color))
+		if nMax < n {
+			fmt.Fprintf(w, "\n")
+			n = 0
+		}
+		fmt.Fprintf(w, "%s  %s\n", strings.Repeat(" ", nMax-n), item.Description)
+	}
+}
+
+// writeParagraphs writes the lines of s wrapped to the terminal width.
+func writeParagraphs(w io.Writer, s string, cols int) {
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		if cols < 60 {
+			fmt.Fprintf(w, "%s\n", line)
+			continue
+		}
+		for {
+			var l string
+			l, line = wrapString(line, cols)
+			fmt.Fprintf(w, "%s\n", l)
+			if len(line) == 0 {
+				break
+			}
+		}
+	}
+}
+
+// outputSize returns the number of columns of the output and whether it is a terminal.
+func outputSize(w io.Writer) (int, bool) {
+	cols, tty := 0, false
+	if f, ok := w.(*os.File); ok {
+		if _, n, err := terminalSize(f.Fd()); err == nil {
+			cols, tty = n, true
+		}
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && 0 < n {
+		cols = n
+	}
+	return cols, tty
+}
+
+func paint(s, code string, color bool) string {
+	if !color {
+		return s
+	}
+	return code + s + colorReset
+}
+
+func quoteValue(val string) string {
+	if space := strings.IndexByte(val, ' '); space != -1 {
+		return "'" + val + "'"
+	}
+	return val
+}
+
+// wrapString splits s at a space such that the first part is at most cols wide.
+func wrapString(s string, cols int) (string, string) {
+	if stringWidth(s) <= cols {
+		return s, ""
+	}
+	minWidth := int(0.8*float64(cols) + 0.5)
+	n, space, end := 0, -1, len(s)
+	for i, r := range s {
+		if r == ' ' && minWidth <= n {
+			space = i
+		}
+		n += runeWidth(r)
+		if cols < n {
+			end = i
+			break
+		}
+	}
+	if space != -1 {
+		return s[:space], s[space+1:]
+	} else if end == 0 {
+		// first character is wider than the columns
+		_, end = utf8.DecodeRuneInString(s)
+	}
+	return s[:end], s[end:]
+}
+
+// stringWidth returns the number of columns of s in a terminal.
+func stringWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// runeWidth returns the number of columns of r in a terminal, which is zero for combining and control characters and two for wide East Asian characters.
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf, unicode.Cc) {
+		return 0
+	} else if 0x1100 <= r && r <= 0x115F || 0x2E80 <= r && r <= 0x303E || 0x3041 <= r && r <= 0x33FF || 0x3400 <= r && r <= 0x4DBF || 0x4E00 <= r && r <= 0x9FFF || 0xA000 <= r && r <= 0xA4CF || 0xAC00 <= r && r <= 0xD7A3 || 0xF900 <= r && r <= 0xFAFF || 0xFE30 <= r && r <= 0xFE4F || 0xFF00 <= r && r <= 0xFF60 || 0xFFE0 <= r && r <= 0xFFE6 || 0x1F300 <= r && r <= 0x1F64F || 0x1F900 <= r && r <= 0x1F9FF || 0x20000 <= r && r <= 0x3FFFD {
+		return 2
+	}
+	return 1
+}
+
+// unquotePlaceholder returns the description without backquotes and the first backquoted word as the placeholder, e.g. "Output `FILE` name" returns "Output FILE name" and "FILE".
+func unquotePlaceholder(desc string) (string, string) {
+	if i := strings.IndexByte(desc, '`'); i != -1 {
+		if j := strings.IndexByte(desc[i+1:], '`'); j != -1 {
+			j += i + 1
+			return desc[:i] + desc[i+1:j] + desc[j+1:], desc[i+1 : j]
+		}
+	}
+	return desc, ""
+}
+
+type optionHelp struct {
+	HelpOption
+	group string
+}
+
+func appendStructHelps(helps []optionHelp, root, group string, v reflect.Value) []optionHelp {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := root + "."
+		if tagName := field.Tag.Get("name"); tagName != "" {
+			name += tagName
+		} else if tagShort := field.Tag.Get("short"); tagShort != "" {
+			name += tagShort
+		} else {
+			name += fromFieldname(field.Name)
+		}
+		if field.Type.Kind() == reflect.Struct {
+			helps = appendStructHelps(helps, name, group, v.Field(i))
+		} else {
+			var val string
+			if deflt := v.Field(i); !deflt.IsZero() {
+				val = fmt.Sprintf("%v", deflt)
+			}
+			typ := TypeName(field.Type)
+			desc, placeholder := unquotePlaceholder(field.Tag.Get("desc"))
+			if tagPlaceholder := field.Tag.Get("placeholder"); tagPlaceholder != "" {
+				placeholder = tagPlaceholder
+			}
+			if placeholder != "" {
+				typ = placeholder
+			}
+			helps = append(helps, optionHelp{
+				HelpOption: HelpOption{
+					Name:        name,
+					Value:       val,
+					Type:        typ,
+					Description: desc,
+				},
+				group: group,
+			})
+		}
+	}
+	return helps
+}
+
+func getOptionHelps(vs []*Var) []optionHelp {
+	helps := []optionHelp{}
+	for _, v := range vs {
+		var val, typ string
+		if custom, ok := v.Value.Interface().(Custom); ok {
+			val, typ = custom.Help()
+		} else if v.Value.Kind() == reflect.Struct {
+			helps = appendStructHelps(helps, v.Name, v.Group, v.Value)
+			continue
+		} else {
+			if v.Default != nil && !reflect.ValueOf(v.Default).IsZero() && !v.Secret {
+				if isBytesType(v.Value.Type()) {
+					val = encodeBytes(reflect.ValueOf(v.Default), "hex")
+				} else {
+					val = fmt.Sprint(v.Default)
+				}
+			}
+			typ = TypeName(v.Value.Type())
+			if v.Secret {
+				typ = "secret"
+			}
+		}
+
+		desc, placeholder := unquotePlaceholder(v.Description)
+		if v.Placeholder != "" {
+			placeholder = v.Placeholder
+		}
+		if placeholder != "" {
+			typ = placeholder
+		}
+		if v.Optional && typ != "" {
+			typ = "[" + typ + "]"
+		}
+
+		var short string
+		if v.Short != 0 {
+			short = string(v.Short)
+		}
+		helps = append(helps, optionHelp{
+			HelpOption: HelpOption{
+				Short:       short,
+				Name:        v.Name,
+				Value:       val,
+				Type:        typ,
+				Description: desc,
+			},
+			group: v.Group,
+		})
+	}
+	return helps
+}
+
+// Help returns the help message of the command.
+func (argp *Argp) Help() *Help {
+	base := argp.name
+	parent := argp.parent
+	for parent != nil {
+		base = parent.name + " " + base
+		parent = parent.parent
+	}
+
+	help := &Help{
+		Name:            base,
+		Description:     argp.Description,
+		LongDescription: argp.LongDescription,
+		Examples:        argp.Examples,
+		Epilog:          argp.Epilog,
+	}
+
+	options := []*Var{}
+	arguments := []*Var{}
+	for _, v := range argp.vars {
+		if v.Hidden {
+			continue
+		} else if v.IsArgument() {
+			arguments = append(arguments, v)
+		} else {
+			options = append(options, v)
+		}
+	}
+	if !argp.KeepOrder {
+		sort.Slice(options, sortOption(options))
+	}
+	sort.Slice(arguments, sortArgument(arguments))
+
+	args := ""
+	if 0 < len(options) {
+		args += " [options]"
+	}
+	if 0 < len(argp.visibleCmds()) {
+		help.Usage = append(help.Usage, fmt.Sprintf("%s%s [command] ...", base, args))
+	}
+	if 0 < len(arguments) {
+		for _, v := range arguments {
+			args += " " + argumentUsage(v)
+		}
+	}
+	if 0 < len(arguments) || len(argp.visibleCmds()) == 0 {
+		help.Usage = append(help.Usage, fmt.Sprintf("%s%s", base, args))
+	}
+
+	if 0 < len(options) {
+		optionHelps := getOptionHelps(options)
+
+		// groups in the given order, then options without group if not listed, then other groups in order of declaration
+		groups := append([]string{}, argp.GroupOrder...)
+		if !containsString(groups, "") {
+			groups = append(groups, "")
+		}
+		for _, v := range argp.vars {
+			if !containsString(groups, v.Group) {
+				groups = append(groups, v.Group)
+			}
+		}
+		for _, group := range groups {
+			section := HelpSection{
+				Name: group,
+			}
+			if group == "" {
+				section.Name = "Options"
+			}
+			for _, o := range optionHelps {
+				if o.group == group {
+					section.Options = append(section.Options, o.HelpOption)
+				}
+			}
+			if 0 < len(section.Options) {
+				help.Options = append(help.Options, section)
+			}
+		}
+	}
+
+	for _, cmd := range argp.visibleCmds() {
+		help.Commands = append(help.Commands, HelpItem{
+			Name:        cmd,
+			Description: argp.cmds[cmd].Description,
+		})
+	}
+
+	for _, v := range arguments {
+		help.Arguments = append(help.Arguments, HelpItem{
+			Name:        v.Name,
+			Description: v.Description,
+		})
+	}
+	return help
+}
+
+// argumentUsage returns the argument's name with its number of values, such as [name...] for zero or more, name... for one or more, and name{2..5} otherwise.
+func argumentUsage(v *Var) string {
+	if !v.IsVariadic() {
+		return v.Name
+	} else if v.Max == -1 && v.Min == 0 {
+		return "[" + v.Name + "...]"
+	} else if v.Max == -1 && v.Min == 1 {
+		return v.Name + "..."
+	} else if v.Max == -1 {
+		return fmt.Sprintf("%s{%d..}", v.Name, v.Min)
+	} else if v.Min == v.Max {
+		return fmt.Sprintf("%s{%d}", v.Name, v.Min)
+	}
+	return fmt.Sprintf("%s{%d..%d}", v.Name, v.Min, v.Max)
+}
+
+// WriteHelp writes the help message to w using the formatter of the command or its nearest parent, or TextFormatter if not set.
+func (argp *Argp) WriteHelp(w io.Writer) error {
+	var formatter HelpFormatter = TextFormatter{}
+	for cmd := argp; cmd != nil; cmd = cmd.parent {
+		if cmd.Formatter != nil {
+			formatter = cmd.Formatter
+			break
+		}
+	}
+	return formatter.FormatHelp(w, argp.Help())
+}
+
+// WriteHelpAll writes the help message of the command and all its sub commands recursively, except for hidden commands.
+func (argp *Argp) WriteHelpAll(w io.Writer) error {
+	if err := argp.WriteHelp(w); err != nil {
+		return err
+	}
+	for _, cmd := range argp.visibleCmds() {
+		if _, err := fmt.Fprintf(w, "\n"); err != nil {
+			return err
+		} else if err := argp.cmds[cmd].WriteHelpAll(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// visibleCmds returns the sorted names of sub commands that are not hidden.
+func (argp *Argp) visibleCmds() []string {
+	cmds := []string{}
+	for cmd, sub := range argp.cmds {
+		if !sub.Hidden {
+			cmds = append(cmds, cmd)
+		}
+	}
+	sort.Strings(cmds)
+	return cmds
+}
+
+// PrintHelpAll prints the help overview of the command and all its sub commands.
+func (argp *Argp) PrintHelpAll() {
+	if err := argp.WriteHelpAll(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+	}
+}
+
+// PrintHelp prints the help overview. This is automatically called when unknown or bad options are passed, but you can call this explicitly in other cases.
+func (argp *Argp) PrintHelp() {
+	if err := argp.WriteHelp(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+	}
+}
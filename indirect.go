@@ -0,0 +1,137 @@
+package argp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// IndirectionFunc returns the contents referred to by an option value without its prefix, such as the filename of file:name.
+type IndirectionFunc func(string) (string, error)
+
+var indirections = map[string]IndirectionFunc{
+	"file:": ReadFileIndirection,
+	"env:":  EnvIndirection,
+	"-":     StdinIndirection,
+}
+
+// AddIndirection registers a prefix that resolves the values of options with the indirect tag, or that have Indirect set. A prefix ending in a colon matches the start of a value, such as file:name, and other prefixes must match the whole value, such as - for standard input.
+func AddIndirection(prefix string, f IndirectionFunc) {
+	indirections[prefix] = f
+}
+
+// ReadFileIndirection returns the contents of a file.
+func ReadFileIndirection(filename string) (string, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// EnvIndirection returns the value of an environment variable.
+func EnvIndirection(name string) (string, error) {
+	val, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %v is not set", name)
+	}
+	return val, nil
+}
+
+// StdinIndirection returns the contents of standard input.
+func StdinIndirection(string) (string, error) {
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// resolveIndirection returns the contents referred to by the value, and false if the value has no registered prefix.
+func resolveIndirection(val string) (string, bool, error) {
+	prefix := ""
+	for p := range indirections {
+		if len(prefix) < len(p) && (p == val || strings.HasSuffix(p, ":") && strings.HasPrefix(val, p)) {
+			prefix = p
+		}
+	}
+	if prefix == "" {
+		return "", false, nil
+	}
+	contents, err := indirections[prefix](val[len(prefix):])
+	if err != nil {
+		return "", false, fmt.Errorf("%v: %v", val, err)
+	}
+	return contents, true, nil
+}
+
+// scanIndirection parses contents into the value at the path of the given name, where each non-empty line is an element of a slice or array and a key=value pair of a map. Strings are set to the contents without trailing newlines.
+func scanIndirection(v reflect.Value, name string, contents string) error {
+	_, err := walkVar(v, name, func(v reflect.Value, name string) (int, error) {
+		if scanner, ok := v.Interface().(Custom); ok {
+			return scanner.Scan(name, []string{strings.TrimRight(contents, "\r\n")})
+		}
+		return 1, scanContents(v, contents)
+	})
+	return err
+}
+
+// scanContents parses contents into the given value.
+func scanContents(v reflect.Value, contents string) error {
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
+		slice := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), 0, 0)
+		scanner := bufio.NewScanner(strings.NewReader(contents))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if len(line) == 0 {
+				continue
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if _, err := scanValue(elem, []string{line}); err != nil {
+				return fmt.Errorf("line %v: %v", line, err)
+			}
+			slice = reflect.Append(slice, elem)
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		} else if v.Kind() == reflect.Array {
+			if slice.Len() != v.Len() {
+				return fmt.Errorf("expected %v values for array", v.Len())
+			}
+			reflect.Copy(v, slice)
+		} else {
+			v.Set(slice)
+		}
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		scanner := bufio.NewScanner(strings.NewReader(contents))
+		for scanner.Scan() {
+			line := scanner.Text()
+			if is := strings.IndexByte(line, '='); is != -1 {
+				key := reflect.New(v.Type().Key()).Elem()
+				if _, err := scanValue(key, []string{strings.TrimSpace(line[:is])}); err != nil {
+					return fmt.Errorf("map key %v: %v", strings.TrimSpace(line[:is]), err)
+				}
+				val := reflect.New(v.Type().Elem()).Elem()
+				if _, err := scanValue(val, []string{strings.TrimSpace(line[is+1:])}); err != nil {
+					return fmt.Errorf("map key %v: %v", strings.TrimSpace(line[:is]), err)
+				}
+				m.SetMapIndex(key, val)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		v.Set(m)
+	case reflect.String:
+		v.SetString(strings.TrimRight(contents, "\r\n"))
+	default:
+		_, err := scanVar(v, "", splitArguments(strings.TrimSpace(contents)))
+		return err
+	}
+	return nil
+}
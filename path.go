@@ -0,0 +1,124 @@
+package argp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+var pathOptions = []string{"file", "dir", "exists", "create", "glob"}
+
+// checkPathOptions panics if the path options are invalid for the type.
+func checkPathOptions(option, options string, t reflect.Type) {
+	isSlice := t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String
+	if t.Kind() != reflect.String && !isSlice {
+		panic(fmt.Sprintf("%v: path must be a string or []string", option))
+	}
+	for _, opt := range strings.Split(options, ",") {
+		if !containsString(pathOptions, opt) {
+			panic(fmt.Sprintf("%v: unknown path option %v", option, opt))
+		} else if opt == "glob" && !isSlice {
+			panic(fmt.Sprintf("%v: glob is only allowed for []string", option))
+		}
+	}
+	if hasPathOption(options, "file") && hasPathOption(options, "dir") {
+		panic(fmt.Sprintf("%v: path can not be both a file and dir", option))
+	}
+}
+
+func hasPathOption(options, opt string) bool {
+	return containsString(strings.Split(options, ","), opt)
+}
+
+// resolvePaths expands ~ and environment variables in the paths of a string or string slice, and makes them absolute relative to dir or the working directory if dir is empty. If check is set, the paths are checked and created according to the comma-separated options, and slices are expanded by glob patterns.
+func resolvePaths(v reflect.Value, options, dir string, check bool) error {
+	if v.Kind() == reflect.String {
+		if v.String() == "" {
+			return nil
+		}
+		path, err := resolvePath(v.String(), options, dir, check)
+		if err != nil {
+			return err
+		}
+		v.SetString(path)
+		return nil
+	}
+
+	paths := []string{}
+	for i := 0; i < v.Len(); i++ {
+		path, err := resolvePath(v.Index(i).String(), "", dir, false)
+		if err != nil {
+			return err
+		}
+
+		matches := []string{path}
+		if check && hasPathOption(options, "glob") {
+			if globMatches, err := filepath.Glob(path); err != nil {
+				return fmt.Errorf("%v: %v", path, err)
+			} else if 0 < len(globMatches) {
+				matches = globMatches
+			}
+		}
+		for _, match := range matches {
+			if check {
+				if err := checkPath(match, options); err != nil {
+					return err
+				}
+			}
+			paths = append(paths, match)
+		}
+	}
+	v.Set(reflect.ValueOf(paths).Convert(v.Type()))
+	return nil
+}
+
+// resolvePath expands ~ and environment variables and makes the path absolute.
+func resolvePath(path, options, dir string, check bool) (string, error) {
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	if dir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	} else if check {
+		if err := checkPath(path, options); err != nil {
+			return "", err
+		}
+	}
+	return path, nil
+}
+
+// checkPath checks the path and creates it according to the options.
+func checkPath(path, options string) error {
+	if hasPathOption(options, "create") {
+		dir := path
+		if !hasPathOption(options, "dir") {
+			dir = filepath.Dir(path)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) && !hasPathOption(options, "exists") {
+		return nil
+	} else if err != nil {
+		return err
+	} else if hasPathOption(options, "file") && info.IsDir() {
+		return fmt.Errorf("%v: is a directory", path)
+	} else if hasPathOption(options, "dir") && !info.IsDir() {
+		return fmt.Errorf("%v: not a directory", path)
+	}
+	return nil
+}
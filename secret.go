@@ -0,0 +1,43 @@
+package argp
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const redacted = "********"
+
+// Secret is a string such as a password that is redacted when printed. Options of this type or with the secret tag never show their value in the help message or error messages. Their value can be read from a file with file:name, from an environment variable with env:NAME, or from standard input with -, which is read without echo from a terminal. A warning is shown when the value is given on the command line, since it may be visible to other users.
+type Secret string
+
+func (secret Secret) String() string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (secret Secret) GoString() string {
+	return strconv.Quote(secret.String())
+}
+
+func (secret Secret) MarshalText() ([]byte, error) {
+	return []byte(secret.String()), nil
+}
+
+// readSecret reads a secret from standard input, which is read without echo after a prompt from a terminal.
+func readSecret(name string) (string, error) {
+	if isTerminal(os.Stdin.Fd()) {
+		fmt.Fprintf(os.Stderr, "%v: ", name)
+		secret, err := readPassword(os.Stdin.Fd())
+		fmt.Fprintf(os.Stderr, "\n")
+		return secret, err
+	}
+	contents, err := StdinIndirection("")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(contents, "\r\n"), nil
+}
@@ -0,0 +1,86 @@
+package argp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Set is an option that sets any option by its path, e.g. --set db.hosts[2].port=5432. Slices are expanded and maps are allocated as needed, and it can be given multiple times. It is not added by default, register it with AddOpt. Values are handled as for the option itself, so that secrets can be indirect and paths are resolved.
+type Set struct {
+	Argp *Argp
+}
+
+func (set Set) Help() (string, string) {
+	return "", "path=value"
+}
+
+func (set Set) Scan(name string, s []string) (int, error) {
+	v, path, val, err := set.Argp.findPath(s)
+	if err != nil {
+		return 0, err
+	}
+
+	vals := []string{val}
+	if 0 < len(val) && (val[0] == '[' || val[0] == '{') {
+		vals = splitArguments(val)
+	}
+	if n, indirect, err := v.scan(path, vals); err != nil {
+		return 0, fmt.Errorf("%s: %v", path, err)
+	} else if !indirect && n != len(vals) {
+		return 0, fmt.Errorf("%s: invalid value", path)
+	} else if v.Secret && !indirect {
+		set.Argp.warn(fmt.Sprintf("option --%s %s is a secret that may be visible to other users, use file:, env:, or - instead", name, path))
+	}
+	v.isSet = true
+	return 1, nil
+}
+
+// SetFile is an option that sets any option by its path to the contents of a file, e.g. --set-file tls.cert=cert.pem. Each line is an element for slices and a key=value pair for maps.
+type SetFile struct {
+	Argp *Argp
+}
+
+func (set SetFile) Help() (string, string) {
+	return "", "path=file"
+}
+
+func (set SetFile) Scan(name string, s []string) (int, error) {
+	v, path, filename, err := set.Argp.findPath(s)
+	if err != nil {
+		return 0, err
+	}
+
+	contents, err := ReadFileIndirection(filename)
+	if err != nil {
+		return 0, err
+	} else if err := scanIndirection(v.Value, path, contents); err != nil {
+		if v.Secret {
+			// do not show the value
+			err = fmt.Errorf("invalid secret")
+		}
+		return 0, fmt.Errorf("%s: %v", path, err)
+	} else if v.Path != "" {
+		if err := resolvePaths(v.Value, v.Path, "", true); err != nil {
+			return 0, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	v.isSet = true
+	return 1, nil
+}
+
+// findPath returns the option, its path and the value of an argument such as path=value.
+func (argp *Argp) findPath(s []string) (*Var, string, string, error) {
+	if len(s) == 0 {
+		return nil, "", "", fmt.Errorf("missing value")
+	}
+	is := strings.IndexByte(s[0], '=')
+	if is == -1 {
+		return nil, "", "", fmt.Errorf("expected path=value")
+	}
+	path, val := s[0][:is], s[0][is+1:]
+	v := argp.findName(path)
+	if v == nil || v.IsArgument() {
+		return nil, "", "", fmt.Errorf("unknown option %s", path)
+	}
+	return v, path, val, nil
+}
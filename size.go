@@ -0,0 +1,106 @@
+package argp
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Size is a size in bytes that accepts SI and IEC suffixes, e.g. 5k, 512MiB, or 1.5GB, and that is shown in human units in the help message.
+type Size int64
+
+func (size Size) String() string {
+	return formatSize(int64(size))
+}
+
+var sizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"Ei", 1 << 60},
+	{"Pi", 1 << 50},
+	{"Ti", 1 << 40},
+	{"Gi", 1 << 30},
+	{"Mi", 1 << 20},
+	{"Ki", 1 << 10},
+	{"E", 1e18},
+	{"P", 1e15},
+	{"T", 1e12},
+	{"G", 1e9},
+	{"M", 1e6},
+	{"k", 1e3},
+}
+
+// parseSize parses a size in bytes with an optional SI or IEC suffix and an optional B, case insensitive.
+func parseSize(s string) (int64, error) {
+	if i, err := parseInt(s); err == nil {
+		return i, nil
+	}
+
+	num, suffix := s, strings.TrimSuffix(strings.ToLower(s), "b")
+	if i := strings.LastIndexAny(suffix, "0123456789."); i != -1 {
+		num, suffix = s[:i+1], suffix[i+1:]
+	}
+	size := int64(1)
+	if suffix != "" {
+		size = 0
+		for _, unit := range sizeUnits {
+			if strings.ToLower(unit.suffix) == suffix {
+				size = unit.size
+				break
+			}
+		}
+		if size == 0 {
+			return 0, fmt.Errorf("invalid size '%v'", s)
+		}
+	}
+
+	if i, err := parseInt(num); err == nil {
+		if i < math.MinInt64/size || math.MaxInt64/size < i {
+			return 0, fmt.Errorf("size out of range '%v'", s)
+		}
+		return i * size, nil
+	} else if f, err := strconv.ParseFloat(num, 64); err == nil {
+		f *= float64(size)
+		if f < math.MinInt64 || math.MaxInt64 <= f {
+			return 0, fmt.Errorf("size out of range '%v'", s)
+		}
+		return int64(math.Round(f)), nil
+	}
+	return 0, fmt.Errorf("invalid size '%v'", s)
+}
+
+// formatSize formats a size in bytes using the largest SI or IEC unit that divides it.
+func formatSize(i int64) string {
+	suffix, size := "", int64(1)
+	if i != 0 {
+		for _, unit := range sizeUnits {
+			if size < unit.size && i%unit.size == 0 {
+				suffix, size = unit.suffix, unit.size
+			}
+		}
+	}
+	return strconv.FormatInt(i/size, 10) + suffix + "B"
+}
+
+// parsePercent parses a percentage such as 50%, or a fraction such as 0.5.
+func parsePercent(s string) (float64, error) {
+	if strings.HasSuffix(s, "%") {
+		f, err := strconv.ParseFloat(s[:len(s)-1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid percentage '%v'", s)
+		}
+		return f / 100.0, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percentage '%v'", s)
+	}
+	return f, nil
+}
+
+// formatPercent formats a fraction as a percentage.
+func formatPercent(f float64) string {
+	return strconv.FormatFloat(f*100.0, 'g', -1, 64) + "%"
+}
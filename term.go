@@ -4,6 +4,11 @@ package argp
 
 import "fmt"
 
+// TerminalSize returns the number of rows and columns of the terminal at standard output.
 func TerminalSize() (int, int, error) {
+	return terminalSize(0)
+}
+
+func terminalSize(fd uintptr) (int, int, error) {
 	return 0, 0, fmt.Errorf("not available")
 }
@@ -0,0 +1,8 @@
+// +build darwin freebsd netbsd openbsd dragonfly
+
+package argp
+
+import "syscall"
+
+const ioctlGetTermios = syscall.TIOCGETA
+const ioctlSetTermios = syscall.TIOCSETA
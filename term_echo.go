@@ -0,0 +1,44 @@
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package argp
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func isTerminal(fd uintptr) bool {
+	var termios syscall.Termios
+	_, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(&termios)))
+	return err == 0
+}
+
+// readPassword reads a line from the terminal without echo.
+func readPassword(fd uintptr) (string, error) {
+	var termios syscall.Termios
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(&termios))); err != 0 {
+		return "", err
+	}
+	noEcho := termios
+	noEcho.Lflag &^= syscall.ECHO
+	noEcho.Lflag |= syscall.ICANON | syscall.ISIG
+	noEcho.Iflag |= syscall.ICRNL
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlSetTermios, uintptr(unsafe.Pointer(&noEcho))); err != 0 {
+		return "", err
+	}
+	defer syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlSetTermios, uintptr(unsafe.Pointer(&termios)))
+
+	line := []byte{}
+	b := make([]byte, 1)
+	for {
+		n, err := syscall.Read(int(fd), b)
+		if err != nil {
+			return "", err
+		} else if n == 0 || b[0] == '\n' {
+			break
+		} else if b[0] != '\r' {
+			line = append(line, b[0])
+		}
+	}
+	return string(line), nil
+}
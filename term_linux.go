@@ -0,0 +1,8 @@
+// +build linux
+
+package argp
+
+import "syscall"
+
+const ioctlGetTermios = syscall.TCGETS
+const ioctlSetTermios = syscall.TCSETS
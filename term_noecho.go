@@ -0,0 +1,13 @@
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package argp
+
+import "fmt"
+
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+func readPassword(fd uintptr) (string, error) {
+	return "", fmt.Errorf("not available")
+}
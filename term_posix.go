@@ -7,14 +7,19 @@ import (
 	"unsafe"
 )
 
+// TerminalSize returns the number of rows and columns of the terminal at standard output.
 func TerminalSize() (int, int, error) {
+	return terminalSize(uintptr(syscall.Stdout))
+}
+
+func terminalSize(fd uintptr) (int, int, error) {
 	data := struct {
 		Row    uint16
 		Col    uint16
 		Xpixel uint16
 		Ypixel uint16
 	}{}
-	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, uintptr(syscall.Stdin), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&data))); err != 0 {
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&data))); err != 0 {
 		return 0, 0, err
 	}
 	return int(data.Row), int(data.Col), nil
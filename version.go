@@ -0,0 +1,70 @@
+package argp
+
+import (
+	"io"
+	"runtime/debug"
+	"text/template"
+)
+
+// DefaultVersionTemplate is the default template to print the version, which is executed with VersionInfo.
+const DefaultVersionTemplate = "{{.Name}} {{.Version}}{{if .Revision}} ({{.Revision}}{{if .Dirty}}, dirty{{end}}){{end}}\n"
+
+// VersionInfo is the version and build information of the program.
+type VersionInfo struct {
+	Name      string
+	Version   string
+	Revision  string // VCS revision
+	Time      string // VCS commit time
+	Dirty     bool   // VCS working tree had local modifications
+	GoVersion string
+}
+
+// SetVersion sets the version and adds the -V and --version options that print the version and exit. If the version is empty, it is obtained from the build information. The output can be changed by setting VersionTemplate.
+func (argp *Argp) SetVersion(version string) {
+	argp.version = version
+	if argp.findName("version") == nil {
+		if argp.findShort('V') == nil {
+			argp.AddOpt(&argp.showVersion, "V", "version", "Version")
+		} else {
+			argp.AddOpt(&argp.showVersion, "", "version", "Version")
+		}
+	}
+}
+
+// VersionInfo returns the version and build information.
+func (argp *Argp) VersionInfo() VersionInfo {
+	info := VersionInfo{
+		Name:    argp.name,
+		Version: argp.version,
+	}
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" {
+			info.Version = buildInfo.Main.Version
+		}
+		info.GoVersion = buildInfo.GoVersion
+		for _, setting := range buildInfo.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Revision = setting.Value
+			case "vcs.time":
+				info.Time = setting.Value
+			case "vcs.modified":
+				info.Dirty = setting.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+// WriteVersion writes the version using VersionTemplate, or DefaultVersionTemplate if not set.
+func (argp *Argp) WriteVersion(w io.Writer) error {
+	text := argp.VersionTemplate
+	if text == "" {
+		text = DefaultVersionTemplate
+	}
+	tmpl, err := template.New("version").Parse(text)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, argp.VersionInfo())
+}